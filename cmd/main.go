@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
 	"github.com/markgregr/bestHack_support_REST_server/internal/app"
 	"github.com/markgregr/bestHack_support_REST_server/internal/config"
-	"github.com/markgregr/bestHack_support_REST_server/internal/lib/logger/handlers/logruspretty"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/logger"
 	"github.com/sirupsen/logrus"
+	"io"
+	"log/slog"
 	"os"
 )
 
@@ -15,11 +18,15 @@ const (
 )
 
 func main() {
+	mockBackend := flag.Bool("mock-backend", false, "run embedded mock SSO, workflow and analytics backends")
+	flag.Parse()
+
 	cfg := config.MustLoad()
+	cfg.MockBackend = *mockBackend || cfg.Env == envLocal
 
-	log := setupLogger(cfg.Env, cfg.LogsPath)
+	log := setupLogger(cfg.Env, cfg.LogsPath, cfg.Log)
 
-	log.WithField("config", cfg).Info("Application start!")
+	log.WithField("config", cfg.Redacted()).Info("Application start!")
 
 	application, err := app.New(cfg, log)
 	if err != nil {
@@ -32,46 +39,40 @@ func main() {
 	log.Info("Application stopped")
 }
 
-func setupLogger(env string, logFilePath string) *logrus.Entry {
-	var log = logrus.New()
-
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
-	}
-
+func setupLogger(env string, logFilePath string, cfg config.Log) *logrus.Entry {
+	level, format := cfg.Level, cfg.Format
 	switch env {
 	case envLocal:
-		log.SetLevel(logrus.DebugLevel)
-		return setupPrettySlog(log)
+		level, format = withDefault(level, "debug"), withDefault(format, logger.FormatPretty)
 	case envDev:
-		log.SetOutput(logFile)
-		log.SetFormatter(&logrus.TextFormatter{
-			DisableColors: true,
-			FullTimestamp: true, // Добавляем временные метки к сообщениям
-		})
-		log.SetLevel(logrus.InfoLevel)
-	case envProd:
-		log.SetOutput(logFile)
-		log.SetFormatter(&logrus.TextFormatter{
-			DisableColors: true,
-			FullTimestamp: true, // Добавляем временные метки к сообщениям
-		})
-		log.SetLevel(logrus.WarnLevel)
+		level, format = withDefault(level, "info"), withDefault(format, logger.FormatJSON)
 	default:
-		log.SetOutput(logFile)
-		log.SetFormatter(&logrus.TextFormatter{
-			DisableColors: true,
-			FullTimestamp: true,
-		})
-		log.SetLevel(logrus.WarnLevel)
+		level, format = withDefault(level, "warn"), withDefault(format, logger.FormatJSON)
+	}
+
+	// Локально логи пишутся в консоль, в остальных окружениях - в файл
+	var out io.Writer = os.Stdout
+	if env != envLocal {
+		logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			panic(err)
+		}
+		out = logFile
 	}
 
-	return logrus.NewEntry(log)
+	l, err := logger.New(out, level, format)
+	if err != nil {
+		panic(err)
+	}
+
+	slog.SetDefault(l.Slog)
+
+	return logrus.NewEntry(l.Logrus)
 }
 
-func setupPrettySlog(log *logrus.Logger) *logrus.Entry {
-	prettyHandler := logruspretty.NewPrettyHandler(os.Stdout)
-	log.SetFormatter(prettyHandler)
-	return logrus.NewEntry(log)
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
 }
@@ -0,0 +1,286 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/filestore"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Prefix совпадает с префиксом, по которому helper.ExtractAPIKey узнает ключи
+	Prefix = helper.APIKeyPrefix
+
+	ScopeTasksCreate = "tasks:create"
+	ScopeTasksRead   = "tasks:read"
+	ScopeCasesRead   = "cases:read"
+
+	flushInterval = 30 * time.Second
+)
+
+var Scopes = []string{ScopeTasksCreate, ScopeTasksRead, ScopeCasesRead}
+
+var (
+	ErrInvalidKey  = errors.New("invalid api key")
+	ErrKeyExpired  = errors.New("api key expired")
+	ErrKeyRevoked  = errors.New("api key revoked")
+	ErrKeyNotFound = errors.New("api key not found")
+)
+
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	UserID     int64      `json:"user_id"`
+	Email      string     `json:"email"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int64      `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CreateParams struct {
+	Name      string
+	UserID    int64
+	Email     string
+	Scopes    []string
+	CreatedBy int64
+	ExpiresAt *time.Time
+}
+
+// Store хранит API ключи в памяти и сохраняет их в JSON файл.
+// Проверка ключа не требует обращения к бэкенду, а время последнего
+// использования сбрасывается на диск периодически.
+type Store struct {
+	log  *logrus.Entry
+	path string
+
+	mu    sync.RWMutex
+	keys  map[string]*Key
+	dirty bool
+}
+
+func NewStore(log *logrus.Logger, path string) (*Store, error) {
+	s := &Store{
+		log:  log.WithField("component", "apikey.Store"),
+		path: path,
+		keys: make(map[string]*Key),
+	}
+
+	var keys []*Key
+	if err := filestore.Load(path, &keys); err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		s.keys[k.ID] = k
+	}
+
+	return s, nil
+}
+
+// Create выпускает новый ключ. Секрет возвращается только один раз,
+// в хранилище остается лишь его хеш.
+func (s *Store) Create(params CreateParams) (*Key, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	token := Prefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	k := &Key{
+		ID:        id,
+		Name:      params.Name,
+		Hash:      hash(token),
+		UserID:    params.UserID,
+		Email:     params.Email,
+		Scopes:    params.Scopes,
+		CreatedBy: params.CreatedBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: params.ExpiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[k.ID] = k
+	if err := s.save(); err != nil {
+		delete(s.keys, k.ID)
+		return nil, "", err
+	}
+
+	c := *k
+	return &c, token, nil
+}
+
+func (s *Store) List() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		c := *k
+		keys = append(keys, &c)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if k.RevokedAt == nil {
+		now := time.Now()
+		k.RevokedAt = &now
+	}
+
+	return s.save()
+}
+
+// Verify проверяет ключ и отмечает время его использования
+func (s *Store) Verify(token string) (*Key, error) {
+	id, ok := parseID(token)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.keys[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash(token))) != 1 {
+		return nil, ErrInvalidKey
+	}
+
+	if k.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+
+	now := time.Now()
+	if k.ExpiresAt != nil && now.After(*k.ExpiresAt) {
+		return nil, ErrKeyExpired
+	}
+
+	k.LastUsedAt = &now
+	s.dirty = true
+
+	c := *k
+	return &c, nil
+}
+
+// Start периодически сохраняет время последнего использования ключей
+func (s *Store) Start(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush()
+			return ctx.Err()
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *Store) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return
+	}
+
+	if err := s.save(); err != nil {
+		s.log.WithError(err).Error("failed to flush api keys")
+	}
+}
+
+func (s *Store) save() error {
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+
+	if err := filestore.Save(s.path, keys); err != nil {
+		return fmt.Errorf("save api keys: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// ValidScope сообщает, известна ли шлюзу область доступа
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseID(token string) (string, bool) {
+	if !strings.HasPrefix(token, Prefix) {
+		return "", false
+	}
+
+	id, _, ok := strings.Cut(strings.TrimPrefix(token, Prefix), "_")
+	return id, ok && id != ""
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,17 +2,39 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/chatex-com/di-container"
 	"github.com/chatex-com/process-manager"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/apikey"
+	"github.com/markgregr/bestHack_support_REST_server/internal/audit"
 	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/clustercache"
 	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/health"
+	"github.com/markgregr/bestHack_support_REST_server/internal/invite"
+	"github.com/markgregr/bestHack_support_REST_server/internal/mockbackend"
+	"github.com/markgregr/bestHack_support_REST_server/internal/oidc"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest/handlers"
+	"github.com/markgregr/bestHack_support_REST_server/internal/revocation"
+	"github.com/markgregr/bestHack_support_REST_server/internal/session"
+	"github.com/markgregr/bestHack_support_REST_server/internal/tracing"
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ipfilter"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/prometheus"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/tlsreload"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 )
 
@@ -54,10 +76,52 @@ func (a *Application) Run() {
 
 func (a *Application) bootstrap() error {
 
+	if err := a.initTracing(); err != nil {
+		return fmt.Errorf("failed to init tracing: %w", err)
+	}
+
+	if err := a.initMockBackend(); err != nil {
+		return fmt.Errorf("failed to init mock backend: %w", err)
+	}
+
 	if err := a.initGRPCWorker(); err != nil {
 		return fmt.Errorf("failed to init GRPC worker: %w", err)
 	}
 
+	if err := a.initHealthChecker(); err != nil {
+		return fmt.Errorf("failed to init health checker: %w", err)
+	}
+
+	if err := a.initRevocationList(); err != nil {
+		return fmt.Errorf("failed to init revocation list: %w", err)
+	}
+
+	if err := a.initSessionManager(); err != nil {
+		return fmt.Errorf("failed to init session manager: %w", err)
+	}
+
+	if err := a.initOIDC(); err != nil {
+		return fmt.Errorf("failed to init oidc: %w", err)
+	}
+
+	if err := a.initAPIKeyStore(); err != nil {
+		return fmt.Errorf("failed to init api key store: %w", err)
+	}
+
+	if err := a.initTwoFactor(); err != nil {
+		return fmt.Errorf("failed to init two-factor authentication: %w", err)
+	}
+
+	if err := a.initInvitationStore(); err != nil {
+		return fmt.Errorf("failed to init invitation store: %w", err)
+	}
+
+	if err := a.initAuditLog(); err != nil {
+		return fmt.Errorf("failed to init audit log: %w", err)
+	}
+
+	a.initClusterCache()
+
 	if err := a.initRestWorker(); err != nil {
 		return fmt.Errorf("failed to init rest worker: %w", err)
 	}
@@ -67,11 +131,95 @@ func (a *Application) bootstrap() error {
 	return nil
 }
 
+// initTracing включает экспорт спанов в OTLP коллектор
+func (a *Application) initTracing() error {
+	const op = "Application.initTracing"
+	log := a.log.WithField("operation", op)
+
+	cfg := &a.cfg.Tracing
+	if !cfg.Enabled {
+		return nil
+	}
+
+	log.WithField("endpoint", cfg.Endpoint).WithField("sample_ratio", cfg.SampleRatio).Info("initializing tracing")
+
+	provider, err := tracing.New(context.Background(), a.log, cfg, a.cfg.Env)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.manager.AddWorker(process.NewCallbackWorker("Tracing exporter", provider.Start))
+	return nil
+}
+
+// mockBackendSecret подставляется вместо секрета приложения, если он не задан при работе с заглушкой SSO
+const mockBackendSecret = "mock-backend-secret"
+
+// initMockBackend запускает заглушки бэкендов и направляет на них gRPC клиент и запросы в аналитику
+func (a *Application) initMockBackend() error {
+	const op = "Application.initMockBackend"
+	log := a.log.WithField("operation", op)
+
+	if !a.cfg.MockBackend {
+		return nil
+	}
+
+	log.Warn("initializing mock backend, requests are not sent to real services")
+
+	// Обработчики проверяют подпись токенов тем же секретом, которым их подписывает заглушка
+	if a.cfg.Auth.AppSecret == "" {
+		log.Warn("app secret is not set, using mock backend secret")
+		a.cfg.Auth.AppSecret = mockBackendSecret
+	}
+
+	mock, err := mockbackend.New(a.log, a.cfg.Auth.AppSecret, a.cfg.Auth.AccessTokenTTL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	grpcCfg := &a.cfg.Clients.GRPC
+	grpcCfg.Address = mockbackend.Address
+	grpcCfg.SSOAddress = ""
+	grpcCfg.WorkflowAddress = ""
+	grpcCfg.Insecure = true
+	grpcCfg.CAFile, grpcCfg.CertFile, grpcCfg.KeyFile = "", "", ""
+
+	a.cfg.AnalURL = mock.AnalyticsURL()
+	a.cfg.Health.AnalyticsURL = ""
+
+	a.container.Set(mock)
+	a.manager.AddWorker(process.NewCallbackWorker("Mock backend", mock.Start))
+	return nil
+}
+
 func (a *Application) initGRPCWorker() error {
 	const op = "Application.initAdminGRPC"
 	a.log.WithField("operation", op).Info("initializing admin grpc")
 
-	apiService, err := grpccli.New(context.Background(), a.log, a.cfg.Clients.GRPC.Address, a.cfg.Clients.GRPC.Timeout, a.cfg.Clients.GRPC.RetriesCount)
+	creds, reloader, err := grpccli.NewTransportCredentials(a.log, &a.cfg.Clients.GRPC)
+	if err != nil {
+		return fmt.Errorf("failed to configure grpc transport security: %w", err)
+	}
+
+	if reloader != nil {
+		a.manager.AddWorker(process.NewCallbackWorker("GRPC TLS reloader", reloader.Start))
+	}
+
+	retryPolicies, err := grpccli.NewRetryPolicies(&a.cfg.Clients.GRPC)
+	if err != nil {
+		return fmt.Errorf("failed to configure grpc retries: %w", err)
+	}
+
+	var dialOpts []grpc.DialOption
+	if a.cfg.MockBackend {
+		var mock *mockbackend.Backend
+		if err := a.container.Load(&mock); err != nil {
+			return fmt.Errorf("failed to load mock backend: %w", err)
+		}
+		dialOpts = append(dialOpts, mock.DialOption())
+	}
+
+	apiService, err := grpccli.New(context.Background(), a.log, &a.cfg.Clients.GRPC, retryPolicies, creds, dialOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create grpc client: %w", err)
 	}
@@ -80,6 +228,263 @@ func (a *Application) initGRPCWorker() error {
 	return nil
 }
 
+func (a *Application) initHealthChecker() error {
+	const op = "Application.initHealthChecker"
+	log := a.log.WithField("operation", op)
+
+	cfg := &a.cfg.Health
+
+	var apiService *grpccli.Client
+	if err := a.container.Load(&apiService); err != nil {
+		return fmt.Errorf("%s: failed to load grpc client: %w", op, err)
+	}
+
+	checker := health.NewChecker(cfg.Timeout)
+	optional := make(map[string]bool, len(cfg.Optional))
+	for _, name := range cfg.Optional {
+		optional[name] = true
+	}
+
+	for _, backend := range apiService.Backends() {
+		backend := backend
+
+		// Idle и Connecting - штатные переходные состояния, ошибкой считается только сбой соединения
+		checker.Add(backend+"_connection", func(ctx context.Context) error {
+			state := apiService.State(backend)
+			if state == connectivity.TransientFailure || state == connectivity.Shutdown {
+				return fmt.Errorf("connection is %s", state)
+			}
+			return nil
+		}, optional[backend+"_connection"])
+
+		if cfg.GRPCHealthCheck {
+			checker.Add(backend+"_health", func(ctx context.Context) error {
+				return apiService.CheckHealth(ctx, backend, cfg.GRPCService)
+			}, optional[backend+"_health"])
+		}
+	}
+
+	analyticsURL := cfg.AnalyticsURL
+	if analyticsURL == "" {
+		analyticsURL = a.cfg.AnalURL
+	}
+	if analyticsURL != "" {
+		checker.Add("analytics", health.HTTPCheck(&http.Client{Timeout: cfg.Timeout}, analyticsURL), optional["analytics"])
+	}
+
+	for name := range optional {
+		if !slices.Contains(checker.Names(), name) {
+			return fmt.Errorf("%s: unknown optional readiness check %q", op, name)
+		}
+	}
+
+	log.WithField("checks", checker.Names()).Info("initializing health checker")
+
+	a.container.Set(checker)
+	return nil
+}
+
+func (a *Application) initRevocationList() error {
+	const op = "Application.initRevocationList"
+	log := a.log.WithField("operation", op)
+	log.Info("initializing token revocation list")
+
+	cfg := &a.cfg.Revocation
+
+	revocations := revocation.NewList(cfg.MaxTTL)
+	a.container.Set(revocations)
+	a.manager.AddWorker(process.NewCallbackWorker("Revocation cleaner", revocations.Start))
+
+	if len(cfg.KafkaBrokers) == 0 {
+		return nil
+	}
+
+	log.WithField("topic", cfg.KafkaTopic).Info("initializing token revocation sync")
+
+	revocationSync, err := revocation.NewKafkaSync(a.log.Logger, revocations, cfg.KafkaBrokers, cfg.KafkaTopic)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revocations.SetPublisher(revocationSync)
+	a.manager.AddWorker(process.NewCallbackWorker("Revocation sync", revocationSync.Start))
+	return nil
+}
+
+func (a *Application) initSessionManager() error {
+	const op = "Application.initSessionManager"
+	log := a.log.WithField("operation", op)
+
+	// Без секрета приложения шлюз не может выпускать access токены при refresh
+	if a.cfg.Auth.AppSecret == "" {
+		log.Warn("app secret is not set, refresh tokens are disabled")
+		return nil
+	}
+
+	log.Info("initializing session manager")
+
+	var revocations *revocation.List
+	if err := a.container.Load(&revocations); err != nil {
+		return fmt.Errorf("%s: failed to load revocation list: %w", op, err)
+	}
+
+	sessions := session.NewManager(a.log.Logger, a.cfg.Auth.RefreshTokenTTL)
+	sessions.SetRevokeHook(revocations.RevokeHash)
+	a.container.Set(sessions)
+	a.manager.AddWorker(process.NewCallbackWorker("Session cleaner", sessions.Start))
+	return nil
+}
+
+func (a *Application) initOIDC() error {
+	const op = "Application.initOIDC"
+	log := a.log.WithField("operation", op)
+
+	cfg := &a.cfg.OIDC
+	if !cfg.Enabled {
+		return nil
+	}
+
+	// После входа через IdP шлюз сам выпускает токены, поэтому без секрета вход невозможен
+	if a.cfg.Auth.AppSecret == "" {
+		return fmt.Errorf("%s: app secret is required for oidc login", op)
+	}
+
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return fmt.Errorf("%s: issuer, client id and redirect url are required", op)
+	}
+
+	log.WithField("issuer", cfg.Issuer).Info("initializing oidc login")
+
+	identities, err := oidc.NewIdentityStore(cfg.IdentityStorePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.container.Set(identities)
+	a.container.Set(oidc.NewProvider(oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	}))
+	return nil
+}
+
+func (a *Application) initAPIKeyStore() error {
+	const op = "Application.initAPIKeyStore"
+	log := a.log.WithField("operation", op)
+
+	// Шлюз выпускает access токены для владельцев ключей, поэтому без секрета ключи недоступны
+	if a.cfg.Auth.AppSecret == "" {
+		log.Warn("app secret is not set, api keys are disabled")
+		return nil
+	}
+
+	log.Info("initializing api key store")
+
+	store, err := apikey.NewStore(a.log.Logger, a.cfg.APIKeys.StorePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.container.Set(store)
+	a.manager.AddWorker(process.NewCallbackWorker("API keys flusher", store.Start))
+	return nil
+}
+
+func (a *Application) initTwoFactor() error {
+	const op = "Application.initTwoFactor"
+	log := a.log.WithField("operation", op)
+
+	if !a.cfg.TwoFactor.Enabled {
+		return nil
+	}
+
+	// Секреты TOTP шифруются ключом, производным от секрета приложения
+	if a.cfg.Auth.AppSecret == "" {
+		return fmt.Errorf("%s: app secret is required for two-factor authentication", op)
+	}
+
+	log.Info("initializing two-factor authentication")
+
+	store, err := twofactor.NewStore(a.cfg.TwoFactor.StorePath, a.cfg.TwoFactor.Issuer, a.cfg.Auth.AppSecret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.container.Set(store)
+	a.container.Set(twofactor.NewChallenges(a.cfg.TwoFactor.ChallengeTTL, a.cfg.TwoFactor.MaxAttempts))
+	return nil
+}
+
+func (a *Application) initInvitationStore() error {
+	const op = "Application.initInvitationStore"
+	log := a.log.WithField("operation", op)
+
+	// Токены приглашений подписываются секретом приложения
+	if a.cfg.Auth.AppSecret == "" {
+		if a.cfg.Invitations.Required {
+			return fmt.Errorf("%s: app secret is required for invite-only registration", op)
+		}
+		log.Warn("app secret is not set, invitations are disabled")
+		return nil
+	}
+
+	log.WithField("required", a.cfg.Invitations.Required).Info("initializing invitation store")
+
+	store, err := invite.NewStore(a.cfg.Invitations.StorePath, a.cfg.Auth.AppSecret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.container.Set(store)
+	return nil
+}
+
+func (a *Application) initAuditLog() error {
+	const op = "Application.initAuditLog"
+	log := a.log.WithField("operation", op)
+
+	if !a.cfg.Audit.Enabled {
+		log.Warn("audit log is disabled")
+		return nil
+	}
+
+	log.WithField("path", a.cfg.Audit.Path).Info("initializing audit log")
+
+	auditLog, err := audit.New(a.cfg.Audit.Path, a.cfg.Audit.MaxSizeMB<<20, a.cfg.Audit.MaxBackups)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.container.Set(auditLog)
+	a.manager.AddWorker(process.NewCallbackWorker("Audit log", func(ctx context.Context) error {
+		<-ctx.Done()
+		return auditLog.Close()
+	}))
+	return nil
+}
+
+func (a *Application) initClusterCache() {
+	const op = "Application.initClusterCache"
+	log := a.log.WithField("operation", op)
+
+	if !a.cfg.Cache.Enabled {
+		return
+	}
+
+	// Ответ из кэша выдается без обращения к бэкенду, поэтому токен проверяет сам шлюз
+	if a.cfg.Auth.AppSecret == "" {
+		log.Warn("app secret is not set, cluster cache is disabled")
+		return
+	}
+
+	log.WithField("ttl", a.cfg.Cache.TTL).Info("initializing cluster cache")
+
+	a.container.Set(clustercache.New(a.cfg.Cache.TTL, a.cfg.Cache.MaxEntries))
+}
+
 func (a *Application) initRestWorker() error {
 	const op = "Application.initRestWorker"
 	a.log.WithField("operation", op).Info(("initializing rest worker"))
@@ -89,16 +494,155 @@ func (a *Application) initRestWorker() error {
 		return fmt.Errorf("%s: failed to load grpc client: %w", op, err)
 	}
 
+	var revocations *revocation.List
+	if err := a.container.Load(&revocations); err != nil {
+		return fmt.Errorf("%s: failed to load revocation list: %w", op, err)
+	}
+
+	var (
+		sessions *session.Manager
+		apiKeys  *apikey.Store
+		invites  *invite.Store
+	)
+	if a.cfg.Auth.AppSecret != "" {
+		if err := a.container.Load(&sessions); err != nil {
+			return fmt.Errorf("%s: failed to load session manager: %w", op, err)
+		}
+		if err := a.container.Load(&apiKeys); err != nil {
+			return fmt.Errorf("%s: failed to load api key store: %w", op, err)
+		}
+		if err := a.container.Load(&invites); err != nil {
+			return fmt.Errorf("%s: failed to load invitation store: %w", op, err)
+		}
+	}
+
+	var (
+		twoFactor  *twofactor.Store
+		challenges *twofactor.Challenges
+	)
+	if a.cfg.TwoFactor.Enabled {
+		if err := a.container.Load(&twoFactor); err != nil {
+			return fmt.Errorf("%s: failed to load two-factor store: %w", op, err)
+		}
+		if err := a.container.Load(&challenges); err != nil {
+			return fmt.Errorf("%s: failed to load two-factor challenges: %w", op, err)
+		}
+	}
+
+	var requiredInvites *invite.Store
+	if a.cfg.Invitations.Required {
+		requiredInvites = invites
+	}
+
+	var auditLog *audit.Log
+	if a.cfg.Audit.Enabled {
+		if err := a.container.Load(&auditLog); err != nil {
+			return fmt.Errorf("%s: failed to load audit log: %w", op, err)
+		}
+	}
+
+	var clusterCache *clustercache.Cache
+	if a.cfg.Cache.Enabled && a.cfg.Auth.AppSecret != "" {
+		if err := a.container.Load(&clusterCache); err != nil {
+			return fmt.Errorf("%s: failed to load cluster cache: %w", op, err)
+		}
+	}
+
+	if err := helper.SetTrustedProxies(a.cfg.HTTPServer.TrustedProxies); err != nil {
+		return fmt.Errorf("%s: failed to configure trusted proxies: %w", op, err)
+	}
+
+	ipFilter, err := ipfilter.New(a.cfg.HTTPServer.IPRules)
+	if err != nil {
+		return fmt.Errorf("%s: failed to configure ip filter: %w", op, err)
+	}
+
+	deadline, err := rest.Deadline(&a.cfg.HTTPServer)
+	if err != nil {
+		return fmt.Errorf("%s: failed to configure request deadlines: %w", op, err)
+	}
+
+	rateLimit, err := rest.RateLimit(&a.cfg.RateLimit, a.cfg.Auth.AppSecret)
+	if err != nil {
+		return fmt.Errorf("%s: failed to configure rate limiting: %w", op, err)
+	}
+
+	var lockout *ratelimit.Lockout
+	if a.cfg.RateLimit.Enabled {
+		lockout = ratelimit.NewLockout(a.cfg.RateLimit.MaxAttempts, a.cfg.RateLimit.BaseLockout, a.cfg.RateLimit.MaxLockout)
+	}
+
+	var checker *health.Checker
+	if err := a.container.Load(&checker); err != nil {
+		return fmt.Errorf("%s: failed to load health checker: %w", op, err)
+	}
+
+	authHandler := handlers.NewAuthHandler(apiService.AuthService, a.log.Logger, a.cfg.AppID, &a.cfg.Auth, sessions, lockout, requiredInvites, twoFactor, challenges, revocations)
+
+	probes := []handlers.APIHandler{
+		handlers.NewHealthHandler(a.log.Logger, checker),
+	}
 	apiHandlers := []handlers.APIHandler{
-		handlers.NewAuthHandler(apiService, a.log.Logger, a.cfg.AppID),
-		handlers.NewTaskHandler(apiService, a.log.Logger, a.cfg.AppID, a.cfg.AnalURL),
-		handlers.NewCaseHandler(apiService, a.log.Logger, a.cfg.AppID),
+		authHandler,
+		handlers.NewTaskHandler(apiService.TaskService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, a.cfg.AnalURL, tracing.HTTPClient(), auditLog),
+		handlers.NewCaseHandler(apiService.CasesService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, auditLog, clusterCache),
+	}
+
+	if a.cfg.OIDC.Enabled {
+		var (
+			provider   *oidc.Provider
+			identities *oidc.IdentityStore
+		)
+		if err := a.container.Load(&provider); err != nil {
+			return fmt.Errorf("%s: failed to load oidc provider: %w", op, err)
+		}
+		if err := a.container.Load(&identities); err != nil {
+			return fmt.Errorf("%s: failed to load oidc identity store: %w", op, err)
+		}
+		apiHandlers = append(apiHandlers, handlers.NewOIDCHandler(a.log.Logger, authHandler, &a.cfg.OIDC, provider, identities))
+	}
+
+	tlsConfig, err := a.initRestTLS()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	middlewares := []gin.HandlerFunc{deadline}
+	if tlsConfig != nil && a.cfg.HTTPServer.TLS.HSTS {
+		middlewares = append(middlewares, middleware.HSTS(a.cfg.HTTPServer.TLS.HSTSMaxAge, a.cfg.HTTPServer.TLS.HSTSIncludeSubdomains))
+	}
+	if !ipFilter.Empty() {
+		middlewares = append(middlewares, middleware.IPFilter(a.log.Logger, ipFilter))
+	}
+	if apiKeys != nil {
+		apiHandlers = append(apiHandlers, handlers.NewAPIKeyHandler(apiService.AuthService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, apiKeys))
+		middlewares = append(middlewares, rest.APIKeyAuth(a.log.Logger, apiKeys, a.cfg.Auth.AppSecret, a.cfg.AppID, a.cfg.APIKeys.TokenTTL))
+	}
+	if sessions != nil {
+		apiHandlers = append(apiHandlers, handlers.NewSessionHandler(a.log.Logger, a.cfg.Auth.AppSecret, sessions))
+	}
+	if twoFactor != nil {
+		apiHandlers = append(apiHandlers, handlers.NewTwoFactorHandler(apiService.AuthService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, twoFactor))
+	}
+	if invites != nil {
+		apiHandlers = append(apiHandlers, handlers.NewInvitationHandler(apiService.AuthService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, &a.cfg.Invitations, invites))
+	}
+	// Просмотр журнала доступен только администраторам, а их проверка требует секрета приложения
+	if auditLog != nil && a.cfg.Auth.AppSecret != "" {
+		apiHandlers = append(apiHandlers, handlers.NewAuditHandler(apiService.AuthService, a.log.Logger, a.cfg.AppID, a.cfg.Auth.AppSecret, auditLog))
+	}
+	middlewares = append(middlewares, middleware.Revocation(a.log.Logger, revocations))
+	if rateLimit != nil {
+		middlewares = append(middlewares, rateLimit)
 	}
 
 	w := rest.NewWorker(
 		&a.cfg.HTTPServer,
 		a.log.Logger,
+		probes,
 		apiHandlers,
+		tlsConfig,
+		middlewares...,
 	)
 
 	cb := process.NewCallbackWorker("Rest server", w.Start)
@@ -107,6 +651,38 @@ func (a *Application) initRestWorker() error {
 	return nil
 }
 
+// initRestTLS настраивает HTTPS для REST сервера. Возвращает nil, если TLS выключен.
+func (a *Application) initRestTLS() (*tls.Config, error) {
+	const op = "Application.initRestTLS"
+	log := a.log.WithField("operation", op)
+
+	cfg := &a.cfg.HTTPServer.TLS
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	log.Info("initializing rest tls")
+
+	reloader, err := tlsreload.New(a.log, cfg.CertFile, cfg.KeyFile, "", cfg.ReloadInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rest tls certificate: %w", err)
+	}
+
+	tlsConfig, err := rest.NewTLSConfig(cfg, reloader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure rest tls: %w", err)
+	}
+
+	a.manager.AddWorker(process.NewCallbackWorker("Rest TLS reloader", reloader.Start))
+
+	if cfg.RedirectHTTP {
+		log.WithField("port", cfg.RedirectPort).Info("initializing http to https redirect")
+		a.manager.AddWorker(process.NewServerWorker("HTTP redirect", rest.NewRedirectServer(&a.cfg.HTTPServer)))
+	}
+
+	return tlsConfig, nil
+}
+
 func (a *Application) initPrometheusWorker() {
 	const op = "Application.initPrometheusWorker"
 	a.log.WithField("operation", op).Info(("initializing prometheus worker"))
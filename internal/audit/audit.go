@@ -0,0 +1,282 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxLineSize = 4 << 20
+
+// Entry - запись журнала аудита об изменяющем действии пользователя
+type Entry struct {
+	ID          string           `json:"id"`
+	Time        time.Time        `json:"time"`
+	ActorID     int64            `json:"actor_id"`
+	ActorEmail  string           `json:"actor_email"`
+	Action      string           `json:"action"`
+	Resource    string           `json:"resource"`
+	ResourceIDs map[string]int64 `json:"resource_ids"`
+	RequestID   string           `json:"request_id,omitempty"`
+	ClientIP    string           `json:"client_ip,omitempty"`
+	Before      json.RawMessage  `json:"before,omitempty"`
+	After       json.RawMessage  `json:"after,omitempty"`
+}
+
+// Filter задает условия выборки записей. Нулевые поля не ограничивают выборку.
+type Filter struct {
+	ActorID    int64
+	Resource   string
+	ResourceID int64
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+func (f Filter) match(e *Entry) bool {
+	if f.ActorID != 0 && e.ActorID != f.ActorID {
+		return false
+	}
+
+	if f.Resource != "" && e.Resource != f.Resource {
+		return false
+	}
+
+	if f.Action != "" && e.Action != f.Action {
+		return false
+	}
+
+	if f.ResourceID != 0 {
+		found := false
+		for _, id := range e.ResourceIDs {
+			if id == f.ResourceID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !f.From.IsZero() && e.Time.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && e.Time.After(f.To) {
+		return false
+	}
+
+	return true
+}
+
+// Log - журнал аудита, который только дописывается. Текущий файл ротируется
+// по достижении maxSize байт, хранится не более maxBackups старых файлов.
+type Log struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func New(path string, maxSize int64, maxBackups int) (*Log, error) {
+	l := &Log{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Record дописывает запись в журнал, заполняя ее идентификатор и время
+func (l *Log) Record(e Entry) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("audit: generate id: %w", err)
+	}
+
+	e.ID = hex.EncodeToString(id)
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("audit: encode entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size+int64(len(line)) > l.maxSize && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("audit: write entry: %w", err)
+	}
+
+	return nil
+}
+
+// Query возвращает подходящие под фильтр записи, начиная с самых новых
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	l.mu.Lock()
+	files, err := l.files()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		fileEntries, err := readFile(file, f)
+		if err != nil {
+			return nil, err
+		}
+
+		// Внутри файла записи идут по возрастанию времени
+		for i := len(fileEntries) - 1; i >= 0; i-- {
+			entries = append(entries, fileEntries[i])
+			if f.Limit > 0 && len(entries) >= f.Limit {
+				return entries, nil
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+func (l *Log) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("audit: create dir: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("audit: open %s: %w", l.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("audit: stat %s: %w", l.path, err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("audit: close %s: %w", l.path, err)
+	}
+
+	backup := fmt.Sprintf("%s.%s", l.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(l.path, backup); err != nil {
+		return fmt.Errorf("audit: rotate %s: %w", l.path, err)
+	}
+
+	backups, err := l.backups()
+	if err != nil {
+		return err
+	}
+
+	if l.maxBackups > 0 && len(backups) > l.maxBackups {
+		for _, old := range backups[l.maxBackups:] {
+			os.Remove(old)
+		}
+	}
+
+	return l.open()
+}
+
+// backups возвращает ротированные файлы, начиная с самого нового
+func (l *Log) backups() ([]string, error) {
+	matches, err := filepath.Glob(l.path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("audit: list backups: %w", err)
+	}
+
+	backups := matches[:0]
+	for _, m := range matches {
+		if !strings.HasSuffix(m, ".tmp") {
+			backups = append(backups, m)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+func (l *Log) files() ([]string, error) {
+	backups, err := l.backups()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{l.path}, backups...), nil
+}
+
+func readFile(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit: open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+
+		if f.match(&e) {
+			entries = append(entries, e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit: read %s: %w", path, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,120 @@
+package fake
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errInvalidCredentials = status.Error(codes.InvalidArgument, "invalid credentials")
+
+func (b *Backend) Register(_ context.Context, in *ssov1.RegisterRequest) (*ssov1.RegisterResponse, error) {
+	if err := validateCredentials(in.GetEmail(), in.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.emails[in.GetEmail()]; ok {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+
+	return &ssov1.RegisterResponse{UserId: b.addUser(in.GetEmail(), in.GetPassword()).id}, nil
+}
+
+func (b *Backend) Login(_ context.Context, in *ssov1.LoginRequest) (*ssov1.LoginResponse, error) {
+	if err := validateCredentials(in.GetEmail(), in.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id, ok := b.emails[in.GetEmail()]
+	if !ok || !b.users[id].checkPassword(in.GetPassword()) {
+		return nil, errInvalidCredentials
+	}
+
+	token, err := b.issueToken(b.users[id], in.GetAppId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate token")
+	}
+
+	return &ssov1.LoginResponse{Token: token}, nil
+}
+
+func (b *Backend) IsAdmin(ctx context.Context, in *ssov1.IsAdminRequest) (*ssov1.IsAdminResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	u, ok := b.users[in.GetUserId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &ssov1.IsAdminResponse{IsAdmin: u.admin}, nil
+}
+
+// Logout отзывает токен из метаданных: последующие вызовы с ним получают Unauthenticated
+func (b *Backend) Logout(ctx context.Context, _ *empty.Empty) (*empty.Empty, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	b.revoked[metadataValue(ctx, "access_token")] = true
+	return &empty.Empty{}, nil
+}
+
+// BotAuth регистрирует бота или подтверждает пароль уже зарегистрированного
+func (b *Backend) BotAuth(_ context.Context, in *ssov1.BotAuthRequest) (*empty.Empty, error) {
+	if err := validateCredentials(in.GetEmail(), in.GetPassword()); err != nil {
+		return nil, err
+	}
+	if in.GetUsername() == "" {
+		return nil, invalidArgument("username", "username is required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id, ok := b.emails[in.GetEmail()]; ok {
+		if !b.users[id].checkPassword(in.GetPassword()) {
+			return nil, errInvalidCredentials
+		}
+		return &empty.Empty{}, nil
+	}
+
+	u := b.addUser(in.GetEmail(), in.GetPassword())
+	u.username = in.GetUsername()
+	u.bot = true
+
+	return &empty.Empty{}, nil
+}
+
+func validateCredentials(email, password string) error {
+	var fields []string
+	if email == "" || !strings.Contains(email, "@") {
+		fields = append(fields, "email", "email is invalid")
+	}
+	if password == "" {
+		fields = append(fields, "password", "password is required")
+	}
+
+	if len(fields) > 0 {
+		return invalidArgument(fields...)
+	}
+
+	return nil
+}
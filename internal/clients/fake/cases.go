@@ -0,0 +1,161 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errClusterNotFound = status.Error(codes.NotFound, "cluster not found")
+
+func (b *Backend) CreateCase(ctx context.Context, in *casesv1.CreateCaseRequest) (*casesv1.Case, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if in.GetTitle() == "" {
+		return nil, invalidArgument("title", "title is required")
+	}
+	if _, ok := b.clusters[in.GetClusterId()]; !ok {
+		return nil, errClusterNotFound
+	}
+
+	c := &caseItem{
+		id:        b.newID(),
+		clusterID: in.GetClusterId(),
+		title:     in.GetTitle(),
+		solution:  in.GetSolution(),
+	}
+	b.cases[c.id] = c
+
+	return b.caseProto(c), nil
+}
+
+func (b *Backend) UpdateCase(ctx context.Context, in *casesv1.UpdateCaseRequest) (*casesv1.Case, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	c, ok := b.cases[in.GetId()]
+	if !ok {
+		return nil, errCaseNotFound
+	}
+
+	if in.Title != nil {
+		if in.GetTitle() == "" {
+			return nil, invalidArgument("title", "title must not be empty")
+		}
+		c.title = in.GetTitle()
+	}
+	if in.Solution != nil {
+		c.solution = in.GetSolution()
+	}
+
+	return b.caseProto(c), nil
+}
+
+// DeleteCase удаляет кейс и отвязывает его от задач
+func (b *Backend) DeleteCase(ctx context.Context, in *casesv1.DeleteCaseRequest) (*empty.Empty, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if _, ok := b.cases[in.GetId()]; !ok {
+		return nil, errCaseNotFound
+	}
+
+	delete(b.cases, in.GetId())
+	for _, t := range b.tasks {
+		if t.caseID == in.GetId() {
+			t.caseID = 0
+		}
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (b *Backend) ListClusters(ctx context.Context, _ *empty.Empty) (*casesv1.ListClustersResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	resp := &casesv1.ListClustersResponse{}
+	for _, id := range sortedIDs(b.clusters) {
+		resp.Clusters = append(resp.Clusters, clusterProto(b.clusters[id]))
+	}
+
+	return resp, nil
+}
+
+func (b *Backend) GetCasesFromCluster(ctx context.Context, in *casesv1.GetCasesFromClusterRequest) (*casesv1.GetCasesFromClusterResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if _, ok := b.clusters[in.GetId()]; !ok {
+		return nil, errClusterNotFound
+	}
+
+	resp := &casesv1.GetCasesFromClusterResponse{}
+	for _, id := range sortedIDs(b.cases) {
+		if c := b.cases[id]; c.clusterID == in.GetId() {
+			resp.Cases = append(resp.Cases, b.caseProto(c))
+		}
+	}
+
+	return resp, nil
+}
+
+func (b *Backend) UpdateClusterName(ctx context.Context, in *casesv1.UpdateClusterNameRequest) (*casesv1.Cluster, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if in.GetName() == "" {
+		return nil, invalidArgument("name", "name is required")
+	}
+
+	cl, ok := b.clusters[in.GetId()]
+	if !ok {
+		return nil, errClusterNotFound
+	}
+	cl.name = in.GetName()
+
+	return clusterProto(cl), nil
+}
+
+// caseProto всегда заполняет Cluster: шлюз обращается к его полям без проверок
+func (b *Backend) caseProto(c *caseItem) *casesv1.Case {
+	resp := &casesv1.Case{
+		Id:       c.id,
+		Cluster:  &casesv1.Cluster{},
+		Title:    c.title,
+		Solution: c.solution,
+	}
+	if cl, ok := b.clusters[c.clusterID]; ok {
+		resp.Cluster = clusterProto(cl)
+	}
+
+	return resp
+}
+
+func clusterProto(cl *cluster) *casesv1.Cluster {
+	return &casesv1.Cluster{Id: cl.id, Name: cl.name, Frequency: cl.frequency}
+}
@@ -0,0 +1,125 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+	"google.golang.org/grpc"
+)
+
+// Auth возвращает клиент SSO поверх Backend. Опции вызова игнорируются.
+func (b *Backend) Auth() grpccli.AuthAPI {
+	return authClient{b}
+}
+
+// Tasks возвращает клиент задач workflow сервиса поверх Backend
+func (b *Backend) Tasks() grpccli.TaskAPI {
+	return taskClient{b}
+}
+
+// Cases возвращает клиент кейсов workflow сервиса поверх Backend
+func (b *Backend) Cases() grpccli.CasesAPI {
+	return casesClient{b}
+}
+
+type authClient struct{ b *Backend }
+
+func (c authClient) Register(ctx context.Context, in *ssov1.RegisterRequest, _ ...grpc.CallOption) (*ssov1.RegisterResponse, error) {
+	return c.b.Register(ctx, in)
+}
+
+func (c authClient) Login(ctx context.Context, in *ssov1.LoginRequest, _ ...grpc.CallOption) (*ssov1.LoginResponse, error) {
+	return c.b.Login(ctx, in)
+}
+
+func (c authClient) IsAdmin(ctx context.Context, in *ssov1.IsAdminRequest, _ ...grpc.CallOption) (*ssov1.IsAdminResponse, error) {
+	return c.b.IsAdmin(ctx, in)
+}
+
+func (c authClient) Logout(ctx context.Context, in *empty.Empty, _ ...grpc.CallOption) (*empty.Empty, error) {
+	return c.b.Logout(ctx, in)
+}
+
+func (c authClient) BotAuth(ctx context.Context, in *ssov1.BotAuthRequest, _ ...grpc.CallOption) (*empty.Empty, error) {
+	return c.b.BotAuth(ctx, in)
+}
+
+type taskClient struct{ b *Backend }
+
+func (c taskClient) CreateTask(ctx context.Context, in *tasksv1.CreateTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.CreateTask(ctx, in)
+}
+
+func (c taskClient) GetTask(ctx context.Context, in *tasksv1.GetTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.GetTask(ctx, in)
+}
+
+func (c taskClient) ListTasks(ctx context.Context, in *tasksv1.ListTasksRequest, _ ...grpc.CallOption) (*tasksv1.ListTasksResponse, error) {
+	return c.b.ListTasks(ctx, in)
+}
+
+func (c taskClient) ChangeTaskStatus(ctx context.Context, in *tasksv1.ChangeTaskStatusRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.ChangeTaskStatus(ctx, in)
+}
+
+func (c taskClient) AddCaseToTask(ctx context.Context, in *tasksv1.AddCaseToTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.AddCaseToTask(ctx, in)
+}
+
+func (c taskClient) AddSolutionToTask(ctx context.Context, in *tasksv1.AddSolutionToTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.AddSolutionToTask(ctx, in)
+}
+
+func (c taskClient) RemoveSolutionFromTask(ctx context.Context, in *tasksv1.RemoveSolutionFromTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.RemoveSolutionFromTask(ctx, in)
+}
+
+func (c taskClient) RemoveCaseFromTask(ctx context.Context, in *tasksv1.RemoveCaseFromTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.RemoveCaseFromTask(ctx, in)
+}
+
+func (c taskClient) AppointUserToTask(ctx context.Context, in *tasksv1.AppointUserToTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.AppointUserToTask(ctx, in)
+}
+
+func (c taskClient) FireTask(ctx context.Context, in *tasksv1.FireTaskRequest, _ ...grpc.CallOption) (*tasksv1.Task, error) {
+	return c.b.FireTask(ctx, in)
+}
+
+func (c taskClient) ListTasksByUserID(ctx context.Context, in *tasksv1.ListTasksByUserIDRequest, _ ...grpc.CallOption) (*tasksv1.ListTasksResponse, error) {
+	return c.b.ListTasksByUserID(ctx, in)
+}
+
+func (c taskClient) ListUsers(ctx context.Context, in *empty.Empty, _ ...grpc.CallOption) (*tasksv1.ListUsersResponse, error) {
+	return c.b.ListUsers(ctx, in)
+}
+
+type casesClient struct{ b *Backend }
+
+func (c casesClient) CreateCase(ctx context.Context, in *casesv1.CreateCaseRequest, _ ...grpc.CallOption) (*casesv1.Case, error) {
+	return c.b.CreateCase(ctx, in)
+}
+
+func (c casesClient) UpdateCase(ctx context.Context, in *casesv1.UpdateCaseRequest, _ ...grpc.CallOption) (*casesv1.Case, error) {
+	return c.b.UpdateCase(ctx, in)
+}
+
+func (c casesClient) DeleteCase(ctx context.Context, in *casesv1.DeleteCaseRequest, _ ...grpc.CallOption) (*empty.Empty, error) {
+	return c.b.DeleteCase(ctx, in)
+}
+
+func (c casesClient) ListClusters(ctx context.Context, in *empty.Empty, _ ...grpc.CallOption) (*casesv1.ListClustersResponse, error) {
+	return c.b.ListClusters(ctx, in)
+}
+
+func (c casesClient) GetCasesFromCluster(ctx context.Context, in *casesv1.GetCasesFromClusterRequest, _ ...grpc.CallOption) (*casesv1.GetCasesFromClusterResponse, error) {
+	return c.b.GetCasesFromCluster(ctx, in)
+}
+
+func (c casesClient) UpdateClusterName(ctx context.Context, in *casesv1.UpdateClusterNameRequest, _ ...grpc.CallOption) (*casesv1.Cluster, error) {
+	return c.b.UpdateClusterName(ctx, in)
+}
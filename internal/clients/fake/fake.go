@@ -0,0 +1,258 @@
+// Package fake - in-memory реализация SSO и workflow бэкендов для тестов
+// и локальной разработки. Повторяет их наблюдаемую семантику: пользователи
+// и токены, задачи и их статусы, кейсы и кластеры.
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TimeLayout - формат дат задач в ответах бэкенда
+const TimeLayout = time.RFC3339
+
+type user struct {
+	id       int64
+	email    string
+	password [sha256.Size]byte
+	username string
+	admin    bool
+	bot      bool
+}
+
+type task struct {
+	id          int64
+	title       string
+	description string
+	status      int32
+	solution    *string
+	caseID      int64
+	clusterID   int64
+	userID      int64
+	fire        bool
+	createdAt   time.Time
+	formedAt    *time.Time
+	completedAt *time.Time
+}
+
+type cluster struct {
+	id        int64
+	name      string
+	frequency int64
+}
+
+type caseItem struct {
+	id        int64
+	clusterID int64
+	title     string
+	solution  string
+}
+
+// Backend хранит состояние бэкендов. Токены подписываются тем же секретом,
+// которым шлюз проверяет access токены.
+type Backend struct {
+	secret   string
+	tokenTTL time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	nextID   int64
+	users    map[int64]*user
+	emails   map[string]int64
+	revoked  map[string]bool
+	tasks    map[int64]*task
+	clusters map[int64]*cluster
+	cases    map[int64]*caseItem
+}
+
+func New(secret string, tokenTTL time.Duration) *Backend {
+	return &Backend{
+		secret:   secret,
+		tokenTTL: tokenTTL,
+		now:      time.Now,
+		users:    make(map[int64]*user),
+		emails:   make(map[string]int64),
+		revoked:  make(map[string]bool),
+		tasks:    make(map[int64]*task),
+		clusters: make(map[int64]*cluster),
+		cases:    make(map[int64]*caseItem),
+	}
+}
+
+// SetClock подменяет источник времени, чтобы даты в ответах были предсказуемыми
+func (b *Backend) SetClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.now = now
+}
+
+// AddUser создает пользователя в обход Register, например администратора
+func (b *Backend) AddUser(email, password string, admin bool) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u := b.addUser(email, password)
+	u.admin = admin
+	return u.id
+}
+
+// AddCluster создает кластер с заданным идентификатором, как его назначил бы сервис аналитики
+func (b *Backend) AddCluster(id int64, name string, frequency int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clusters[id] = &cluster{id: id, name: name, frequency: frequency}
+	if id > b.nextID {
+		b.nextID = id
+	}
+}
+
+func (b *Backend) AddCase(clusterID int64, title, solution string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.cases[id] = &caseItem{id: id, clusterID: clusterID, title: title, solution: solution}
+	return id
+}
+
+// AddTask создает открытую задачу в кластере в обход CreateTask
+func (b *Backend) AddTask(clusterID int64, title, description string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.tasks[id] = &task{
+		id:          id,
+		title:       title,
+		description: description,
+		clusterID:   clusterID,
+		createdAt:   b.now(),
+	}
+	return id
+}
+
+// IssueToken выпускает access token для существующего пользователя
+func (b *Backend) IssueToken(userID int64, appID int32) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u, ok := b.users[userID]
+	if !ok {
+		return "", status.Error(codes.NotFound, "user not found")
+	}
+
+	return b.issueToken(u, appID)
+}
+
+func (b *Backend) newID() int64 {
+	b.nextID++
+	return b.nextID
+}
+
+func (b *Backend) addUser(email, password string) *user {
+	u := &user{
+		id:       b.newID(),
+		email:    email,
+		password: sha256.Sum256([]byte(password)),
+	}
+	b.users[u.id] = u
+	b.emails[email] = u.id
+	return u
+}
+
+func (u *user) checkPassword(password string) bool {
+	hash := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(u.password[:], hash[:]) == 1
+}
+
+func (b *Backend) issueToken(u *user, appID int32) (string, error) {
+	return jwt.Sign(jwt.Claims{
+		UserID:    u.id,
+		Email:     u.email,
+		AppID:     appID,
+		ExpiresAt: b.now().Add(b.tokenTTL).Unix(),
+	}, b.secret)
+}
+
+// authorize возвращает пользователя по access_token из метаданных. Клиентская
+// реализация читает исходящие метаданные, gRPC сервер - входящие.
+func (b *Backend) authorize(ctx context.Context) (*user, error) {
+	token := metadataValue(ctx, "access_token")
+	if token == "" || b.revoked[token] {
+		return nil, errUnauthenticated
+	}
+
+	claims, err := jwt.Parse(token, b.secret)
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+
+	u, ok := b.users[claims.UserID]
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	return u, nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// errUnauthenticated повторяет ответ SSO, который шлюз распознает по тексту
+var errUnauthenticated = status.Error(codes.Unauthenticated, "Unauthenticated")
+
+// invalidArgument возвращает InvalidArgument с нарушениями полей, как это
+// делают бэкенды при ошибках валидации. fields - пары поле, описание.
+func invalidArgument(fields ...string) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       fields[i],
+			Description: fields[i+1],
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, "invalid argument").WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid argument")
+	}
+
+	return st.Err()
+}
+
+func sortedIDs[T any](items map[int64]T) []int64 {
+	ids := make([]int64, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
@@ -0,0 +1,126 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+	"google.golang.org/grpc"
+)
+
+// RegisterServices регистрирует SSO и workflow сервисы поверх Backend на gRPC сервере.
+// Сервер читает access_token из входящих метаданных.
+func (b *Backend) RegisterServices(s grpc.ServiceRegistrar) {
+	ssov1.RegisterAuthServer(s, authServer{b: b})
+	tasksv1.RegisterTaskServiceServer(s, taskServer{b: b})
+	casesv1.RegisterCaseServiceServer(s, casesServer{b: b})
+}
+
+type authServer struct {
+	ssov1.UnimplementedAuthServer
+	b *Backend
+}
+
+func (s authServer) Register(ctx context.Context, in *ssov1.RegisterRequest) (*ssov1.RegisterResponse, error) {
+	return s.b.Register(ctx, in)
+}
+
+func (s authServer) Login(ctx context.Context, in *ssov1.LoginRequest) (*ssov1.LoginResponse, error) {
+	return s.b.Login(ctx, in)
+}
+
+func (s authServer) IsAdmin(ctx context.Context, in *ssov1.IsAdminRequest) (*ssov1.IsAdminResponse, error) {
+	return s.b.IsAdmin(ctx, in)
+}
+
+func (s authServer) Logout(ctx context.Context, in *empty.Empty) (*empty.Empty, error) {
+	return s.b.Logout(ctx, in)
+}
+
+func (s authServer) BotAuth(ctx context.Context, in *ssov1.BotAuthRequest) (*empty.Empty, error) {
+	return s.b.BotAuth(ctx, in)
+}
+
+type taskServer struct {
+	tasksv1.UnimplementedTaskServiceServer
+	b *Backend
+}
+
+func (s taskServer) CreateTask(ctx context.Context, in *tasksv1.CreateTaskRequest) (*tasksv1.Task, error) {
+	return s.b.CreateTask(ctx, in)
+}
+
+func (s taskServer) GetTask(ctx context.Context, in *tasksv1.GetTaskRequest) (*tasksv1.Task, error) {
+	return s.b.GetTask(ctx, in)
+}
+
+func (s taskServer) ListTasks(ctx context.Context, in *tasksv1.ListTasksRequest) (*tasksv1.ListTasksResponse, error) {
+	return s.b.ListTasks(ctx, in)
+}
+
+func (s taskServer) ChangeTaskStatus(ctx context.Context, in *tasksv1.ChangeTaskStatusRequest) (*tasksv1.Task, error) {
+	return s.b.ChangeTaskStatus(ctx, in)
+}
+
+func (s taskServer) AddCaseToTask(ctx context.Context, in *tasksv1.AddCaseToTaskRequest) (*tasksv1.Task, error) {
+	return s.b.AddCaseToTask(ctx, in)
+}
+
+func (s taskServer) AddSolutionToTask(ctx context.Context, in *tasksv1.AddSolutionToTaskRequest) (*tasksv1.Task, error) {
+	return s.b.AddSolutionToTask(ctx, in)
+}
+
+func (s taskServer) RemoveSolutionFromTask(ctx context.Context, in *tasksv1.RemoveSolutionFromTaskRequest) (*tasksv1.Task, error) {
+	return s.b.RemoveSolutionFromTask(ctx, in)
+}
+
+func (s taskServer) RemoveCaseFromTask(ctx context.Context, in *tasksv1.RemoveCaseFromTaskRequest) (*tasksv1.Task, error) {
+	return s.b.RemoveCaseFromTask(ctx, in)
+}
+
+func (s taskServer) AppointUserToTask(ctx context.Context, in *tasksv1.AppointUserToTaskRequest) (*tasksv1.Task, error) {
+	return s.b.AppointUserToTask(ctx, in)
+}
+
+func (s taskServer) FireTask(ctx context.Context, in *tasksv1.FireTaskRequest) (*tasksv1.Task, error) {
+	return s.b.FireTask(ctx, in)
+}
+
+func (s taskServer) ListTasksByUserID(ctx context.Context, in *tasksv1.ListTasksByUserIDRequest) (*tasksv1.ListTasksResponse, error) {
+	return s.b.ListTasksByUserID(ctx, in)
+}
+
+func (s taskServer) ListUsers(ctx context.Context, in *empty.Empty) (*tasksv1.ListUsersResponse, error) {
+	return s.b.ListUsers(ctx, in)
+}
+
+type casesServer struct {
+	casesv1.UnimplementedCaseServiceServer
+	b *Backend
+}
+
+func (s casesServer) CreateCase(ctx context.Context, in *casesv1.CreateCaseRequest) (*casesv1.Case, error) {
+	return s.b.CreateCase(ctx, in)
+}
+
+func (s casesServer) UpdateCase(ctx context.Context, in *casesv1.UpdateCaseRequest) (*casesv1.Case, error) {
+	return s.b.UpdateCase(ctx, in)
+}
+
+func (s casesServer) DeleteCase(ctx context.Context, in *casesv1.DeleteCaseRequest) (*empty.Empty, error) {
+	return s.b.DeleteCase(ctx, in)
+}
+
+func (s casesServer) ListClusters(ctx context.Context, in *empty.Empty) (*casesv1.ListClustersResponse, error) {
+	return s.b.ListClusters(ctx, in)
+}
+
+func (s casesServer) GetCasesFromCluster(ctx context.Context, in *casesv1.GetCasesFromClusterRequest) (*casesv1.GetCasesFromClusterResponse, error) {
+	return s.b.GetCasesFromCluster(ctx, in)
+}
+
+func (s casesServer) UpdateClusterName(ctx context.Context, in *casesv1.UpdateClusterNameRequest) (*casesv1.Cluster, error) {
+	return s.b.UpdateClusterName(ctx, in)
+}
@@ -0,0 +1,324 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errTaskNotFound  = status.Error(codes.NotFound, "task not found")
+	errCaseNotFound  = status.Error(codes.NotFound, "case not found")
+	errTaskClosed    = status.Error(codes.FailedPrecondition, "task is closed")
+	errNoFreeSupport = status.Error(codes.FailedPrecondition, "no users to appoint")
+)
+
+func (b *Backend) CreateTask(ctx context.Context, in *tasksv1.CreateTaskRequest) (*tasksv1.Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if in.GetTitle() == "" {
+		return nil, invalidArgument("title", "title is required")
+	}
+
+	cl, ok := b.clusters[in.GetClusterIndex()]
+	if !ok {
+		cl = &cluster{id: in.GetClusterIndex(), name: in.GetClusterName()}
+		b.clusters[cl.id] = cl
+	}
+	cl.frequency = in.GetFrequency()
+
+	t := &task{
+		id:          b.newID(),
+		title:       in.GetTitle(),
+		description: in.GetDescription(),
+		status:      int32(tasksv1.TaskStatus_OPEN),
+		clusterID:   cl.id,
+		createdAt:   b.now(),
+	}
+	b.tasks[t.id] = t
+
+	return b.taskProto(t), nil
+}
+
+func (b *Backend) GetTask(ctx context.Context, in *tasksv1.GetTaskRequest) (*tasksv1.Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	t, ok := b.tasks[in.GetTaskId()]
+	if !ok {
+		return nil, errTaskNotFound
+	}
+
+	return b.taskProto(t), nil
+}
+
+func (b *Backend) ListTasks(ctx context.Context, in *tasksv1.ListTasksRequest) (*tasksv1.ListTasksResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.listTasks(func(t *task) bool {
+		return int64(t.status) == in.GetStatus()
+	}), nil
+}
+
+// ChangeTaskStatus переводит задачу на следующий шаг: OPEN -> IN_PROGRESS -> CLOSED.
+// Взятая в работу задача без исполнителя назначается на вызывающего.
+func (b *Backend) ChangeTaskStatus(ctx context.Context, in *tasksv1.ChangeTaskStatusRequest) (*tasksv1.Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u, err := b.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := b.tasks[in.GetTaskId()]
+	if !ok {
+		return nil, errTaskNotFound
+	}
+
+	now := b.now()
+	switch tasksv1.TaskStatus(t.status) {
+	case tasksv1.TaskStatus_OPEN:
+		t.status = int32(tasksv1.TaskStatus_IN_PROGRESS)
+		t.formedAt = &now
+		if t.userID == 0 {
+			t.userID = u.id
+		}
+	case tasksv1.TaskStatus_IN_PROGRESS:
+		t.status = int32(tasksv1.TaskStatus_CLOSED)
+		t.completedAt = &now
+		t.fire = false
+	default:
+		return nil, errTaskClosed
+	}
+
+	return b.taskProto(t), nil
+}
+
+func (b *Backend) AddCaseToTask(ctx context.Context, in *tasksv1.AddCaseToTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		if _, ok := b.cases[in.GetCaseId()]; !ok {
+			return errCaseNotFound
+		}
+		t.caseID = in.GetCaseId()
+		return nil
+	})
+}
+
+func (b *Backend) AddSolutionToTask(ctx context.Context, in *tasksv1.AddSolutionToTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		if in.GetSolution() == "" {
+			return invalidArgument("solution", "solution is required")
+		}
+		solution := in.GetSolution()
+		t.solution = &solution
+		return nil
+	})
+}
+
+func (b *Backend) RemoveSolutionFromTask(ctx context.Context, in *tasksv1.RemoveSolutionFromTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		t.solution = nil
+		return nil
+	})
+}
+
+func (b *Backend) RemoveCaseFromTask(ctx context.Context, in *tasksv1.RemoveCaseFromTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		t.caseID = 0
+		return nil
+	})
+}
+
+// AppointUserToTask назначает задачу на сотрудника с наименьшим числом задач в работе
+func (b *Backend) AppointUserToTask(ctx context.Context, in *tasksv1.AppointUserToTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		load := make(map[int64]int)
+		for _, other := range b.tasks {
+			if tasksv1.TaskStatus(other.status) == tasksv1.TaskStatus_IN_PROGRESS {
+				load[other.userID]++
+			}
+		}
+
+		var appointee *user
+		for _, id := range sortedIDs(b.users) {
+			u := b.users[id]
+			if u.bot {
+				continue
+			}
+			if appointee == nil || load[u.id] < load[appointee.id] {
+				appointee = u
+			}
+		}
+		if appointee == nil {
+			return errNoFreeSupport
+		}
+
+		t.userID = appointee.id
+		return nil
+	})
+}
+
+func (b *Backend) FireTask(ctx context.Context, in *tasksv1.FireTaskRequest) (*tasksv1.Task, error) {
+	return b.updateTask(ctx, in.GetTaskId(), func(t *task) error {
+		t.fire = true
+		return nil
+	})
+}
+
+func (b *Backend) ListTasksByUserID(ctx context.Context, in *tasksv1.ListTasksByUserIDRequest) (*tasksv1.ListTasksResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return b.listTasks(func(t *task) bool {
+		return t.userID == in.GetUserId() && int64(t.status) == in.GetStatus()
+	}), nil
+}
+
+// ListUsers возвращает сотрудников поддержки со средним временем решения задач в минутах
+func (b *Backend) ListUsers(ctx context.Context, _ *empty.Empty) (*tasksv1.ListUsersResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	resp := &tasksv1.ListUsersResponse{}
+	for _, id := range sortedIDs(b.users) {
+		u := b.users[id]
+		if u.bot {
+			continue
+		}
+		resp.Users = append(resp.Users, &tasksv1.User{
+			Id:              u.id,
+			Email:           u.email,
+			AvarageDuration: b.averageDuration(u.id),
+		})
+	}
+
+	return resp, nil
+}
+
+// updateTask изменяет открытую или взятую в работу задачу под блокировкой
+func (b *Backend) updateTask(ctx context.Context, id int64, update func(t *task) error) (*tasksv1.Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	t, ok := b.tasks[id]
+	if !ok {
+		return nil, errTaskNotFound
+	}
+	if tasksv1.TaskStatus(t.status) == tasksv1.TaskStatus_CLOSED {
+		return nil, errTaskClosed
+	}
+
+	if err := update(t); err != nil {
+		return nil, err
+	}
+
+	return b.taskProto(t), nil
+}
+
+func (b *Backend) listTasks(match func(t *task) bool) *tasksv1.ListTasksResponse {
+	resp := &tasksv1.ListTasksResponse{}
+	for _, id := range sortedIDs(b.tasks) {
+		if t := b.tasks[id]; match(t) {
+			resp.Tasks = append(resp.Tasks, b.taskProto(t))
+		}
+	}
+
+	return resp
+}
+
+func (b *Backend) averageDuration(userID int64) float32 {
+	var (
+		total time.Duration
+		count int
+	)
+	for _, t := range b.tasks {
+		if t.userID != userID || t.formedAt == nil || t.completedAt == nil {
+			continue
+		}
+		total += t.completedAt.Sub(*t.formedAt)
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return float32(total.Minutes() / float64(count))
+}
+
+// taskProto собирает ответ бэкенда. Вложенные Case, Cluster и User всегда
+// заполнены, даже если связи нет: шлюз обращается к их полям без проверок.
+func (b *Backend) taskProto(t *task) *tasksv1.Task {
+	resp := &tasksv1.Task{
+		Id:          t.id,
+		Title:       t.title,
+		Description: t.description,
+		Status:      tasksv1.TaskStatus(t.status),
+		Case:        &tasksv1.Case{},
+		Cluster:     &tasksv1.Cluster{},
+		User:        &tasksv1.User{},
+		CreatedAt:   t.createdAt.Format(TimeLayout),
+		Solution:    t.solution,
+		FormedAt:    formatTime(t.formedAt),
+		CompletedAt: formatTime(t.completedAt),
+		Fire:        t.fire,
+	}
+
+	if c, ok := b.cases[t.caseID]; ok {
+		resp.Case = &tasksv1.Case{Id: c.id, ClusterId: c.clusterID, Title: c.title, Solution: c.solution}
+	}
+	if cl, ok := b.clusters[t.clusterID]; ok {
+		resp.Cluster = &tasksv1.Cluster{Id: cl.id, Name: cl.name, Frequency: cl.frequency}
+		for _, id := range sortedIDs(b.cases) {
+			if c := b.cases[id]; c.clusterID == cl.id {
+				resp.Cluster.Cases = append(resp.Cluster.Cases, &tasksv1.Case{
+					Id: c.id, ClusterId: c.clusterID, Title: c.title, Solution: c.solution,
+				})
+			}
+		}
+	}
+	if u, ok := b.users[t.userID]; ok {
+		resp.User = &tasksv1.User{Id: u.id, Email: u.email}
+	}
+
+	return resp
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	formatted := t.Format(TimeLayout)
+	return &formatted
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+	"google.golang.org/grpc"
+)
+
+// AdminAPI - проверка прав администратора, которая нужна обработчикам только для доступа к маршрутам
+type AdminAPI interface {
+	IsAdmin(ctx context.Context, in *ssov1.IsAdminRequest, opts ...grpc.CallOption) (*ssov1.IsAdminResponse, error)
+}
+
+// AuthAPI - операции SSO, которые использует шлюз. Ему удовлетворяют
+// сгенерированный gRPC клиент и in-memory реализация из пакета fake.
+// Интерфейсы содержат только вызываемые обработчиками методы, поэтому fake
+// реализует ровно их, а новый метод бэкенда добавляется сюда вместе с обработчиком.
+type AuthAPI interface {
+	AdminAPI
+	Register(ctx context.Context, in *ssov1.RegisterRequest, opts ...grpc.CallOption) (*ssov1.RegisterResponse, error)
+	Login(ctx context.Context, in *ssov1.LoginRequest, opts ...grpc.CallOption) (*ssov1.LoginResponse, error)
+	Logout(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	BotAuth(ctx context.Context, in *ssov1.BotAuthRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+}
+
+// TaskAPI - операции с задачами workflow сервиса, которые использует шлюз
+type TaskAPI interface {
+	CreateTask(ctx context.Context, in *tasksv1.CreateTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	GetTask(ctx context.Context, in *tasksv1.GetTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	ListTasks(ctx context.Context, in *tasksv1.ListTasksRequest, opts ...grpc.CallOption) (*tasksv1.ListTasksResponse, error)
+	ChangeTaskStatus(ctx context.Context, in *tasksv1.ChangeTaskStatusRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	AddCaseToTask(ctx context.Context, in *tasksv1.AddCaseToTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	AddSolutionToTask(ctx context.Context, in *tasksv1.AddSolutionToTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	RemoveSolutionFromTask(ctx context.Context, in *tasksv1.RemoveSolutionFromTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	RemoveCaseFromTask(ctx context.Context, in *tasksv1.RemoveCaseFromTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	AppointUserToTask(ctx context.Context, in *tasksv1.AppointUserToTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	FireTask(ctx context.Context, in *tasksv1.FireTaskRequest, opts ...grpc.CallOption) (*tasksv1.Task, error)
+	ListTasksByUserID(ctx context.Context, in *tasksv1.ListTasksByUserIDRequest, opts ...grpc.CallOption) (*tasksv1.ListTasksResponse, error)
+	ListUsers(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*tasksv1.ListUsersResponse, error)
+}
+
+// CasesAPI - операции с кейсами и кластерами workflow сервиса, которые использует шлюз
+type CasesAPI interface {
+	CreateCase(ctx context.Context, in *casesv1.CreateCaseRequest, opts ...grpc.CallOption) (*casesv1.Case, error)
+	UpdateCase(ctx context.Context, in *casesv1.UpdateCaseRequest, opts ...grpc.CallOption) (*casesv1.Case, error)
+	DeleteCase(ctx context.Context, in *casesv1.DeleteCaseRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ListClusters(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*casesv1.ListClustersResponse, error)
+	GetCasesFromCluster(ctx context.Context, in *casesv1.GetCasesFromClusterRequest, opts ...grpc.CallOption) (*casesv1.GetCasesFromClusterResponse, error)
+	UpdateClusterName(ctx context.Context, in *casesv1.UpdateClusterNameRequest, opts ...grpc.CallOption) (*casesv1.Cluster, error)
+}
+
+var (
+	_ AuthAPI  = ssov1.AuthClient(nil)
+	_ TaskAPI  = tasksv1.TaskServiceClient(nil)
+	_ CasesAPI = casesv1.CaseServiceClient(nil)
+)
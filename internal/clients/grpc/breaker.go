@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/circuitbreaker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ServiceAuth  = "auth"
+	ServiceTasks = "tasks"
+	ServiceCases = "cases"
+)
+
+// servicePrefixes сопоставляет gRPC сервисы автоматам. Вызовы health check
+// не проходят через автоматы, чтобы проверка готовности видела реальное состояние.
+var servicePrefixes = map[string]string{
+	"/auth.Auth/":         ServiceAuth,
+	"/tasks.TaskService/": ServiceTasks,
+	"/cases.CaseService/": ServiceCases,
+}
+
+var (
+	breakerState       *prometheus.GaugeVec
+	breakerTransitions *prometheus.CounterVec
+	breakerRejected    *prometheus.CounterVec
+)
+
+func init() {
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "Current circuit breaker state per backend service: 0 - closed, 1 - half-open, 2 - open.",
+	}, []string{"service"})
+
+	if err := prometheus.Register(breakerState); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_circuit_breaker_state").
+			Error("unable to register prometheus metric")
+	}
+
+	breakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_transitions_total",
+		Help: "How many times circuit breakers changed state, partitioned by service and states.",
+	}, []string{"service", "from", "to"})
+
+	if err := prometheus.Register(breakerTransitions); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_circuit_breaker_transitions_total").
+			Error("unable to register prometheus metric")
+	}
+
+	breakerRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_rejected_total",
+		Help: "How many gRPC calls were rejected by an open circuit breaker, partitioned by service.",
+	}, []string{"service"})
+
+	if err := prometheus.Register(breakerRejected); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_circuit_breaker_rejected_total").
+			Error("unable to register prometheus metric")
+	}
+}
+
+type breakers struct {
+	byService map[string]*circuitbreaker.Breaker
+	failures  []codes.Code
+}
+
+// newBreakers создает автоматы для сервисов Auth, Task и Cases. Возвращает nil,
+// если автоматы выключены.
+func newBreakers(log *logrus.Entry, cfg *config.GRPCClient) (*breakers, error) {
+	if !cfg.BreakerEnabled {
+		return nil, nil
+	}
+
+	failures, err := ParseCodes(cfg.BreakerCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := circuitbreaker.Settings{
+		FailureThreshold:    cfg.BreakerFailureThreshold,
+		SuccessThreshold:    cfg.BreakerSuccessThreshold,
+		OpenTimeout:         cfg.BreakerOpenTimeout,
+		HalfOpenMaxRequests: cfg.BreakerHalfOpenRequests,
+		OnStateChange: func(name string, from, to circuitbreaker.State) {
+			breakerState.WithLabelValues(name).Set(float64(to))
+			breakerTransitions.WithLabelValues(name, from.String(), to.String()).Inc()
+			log.WithField("service", name).WithField("from", from.String()).WithField("to", to.String()).Warn("circuit breaker state changed")
+		},
+	}
+
+	b := &breakers{
+		byService: make(map[string]*circuitbreaker.Breaker, len(servicePrefixes)),
+		failures:  failures,
+	}
+	for _, service := range servicePrefixes {
+		b.byService[service] = circuitbreaker.New(service, settings)
+		breakerState.WithLabelValues(service).Set(float64(circuitbreaker.StateClosed))
+	}
+
+	return b, nil
+}
+
+func (b *breakers) forMethod(method string) *circuitbreaker.Breaker {
+	for prefix, service := range servicePrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return b.byService[service]
+		}
+	}
+
+	return nil
+}
+
+// failed считает вызов неудачным, только если код ответа говорит о проблемах
+// бэкенда. Отмена запроса клиентом на автомат не влияет.
+func (b *breakers) failed(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() == context.Canceled {
+		return false
+	}
+
+	code := status.Code(err)
+	for _, c := range b.failures {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// breakerInterceptor стоит перед retry, поэтому разомкнутый автомат отклоняет
+// вызов сразу, а серия повторов считается одним вызовом
+func breakerInterceptor(b *breakers) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		breaker := b.forMethod(method)
+		if breaker == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		done, err := breaker.Allow()
+		if err != nil {
+			breakerRejected.WithLabelValues(breaker.Name()).Inc()
+			return err
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(b.failed(ctx, err))
+
+		return err
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"context"
 	"fmt"
 	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
-	grpcretry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
 	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
 	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
 	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
-	"time"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	BackendSSO      = "sso"
+	BackendWorkflow = "workflow"
 )
 
 type Client struct {
@@ -20,36 +27,140 @@ type Client struct {
 	TaskService  tasksv1.TaskServiceClient
 	CasesService casesv1.CaseServiceClient
 	log          *logrus.Entry
+	conns        map[string]*grpc.ClientConn
 }
 
-func New(ctx context.Context, log *logrus.Entry, targetAddr string, timeout time.Duration, retriesCount int) (*Client, error) {
+// New подключается к SSO и workflow бэкендам. Если их адреса совпадают,
+// используется одно соединение. extra дополняет параметры подключения.
+func New(ctx context.Context, log *logrus.Entry, cfg *config.GRPCClient, retryPolicies *RetryPolicies, creds credentials.TransportCredentials, extra ...grpc.DialOption) (*Client, error) {
 	const op = "grpc.New"
 	log = log.WithField("operation", op)
-	log.Infof("dialing to %s", targetAddr)
 
-	retryOpts := []grpcretry.CallOption{
-		grpcretry.WithCodes(codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.ResourceExhausted, codes.PermissionDenied, codes.Unimplemented),
-		grpcretry.WithMax(uint(retriesCount)),
-		grpcretry.WithBackoff(grpcretry.BackoffLinear(timeout)),
-		grpcretry.WithPerRetryTimeout(timeout),
+	if cfg.LBPolicy != "pick_first" && cfg.LBPolicy != "round_robin" {
+		return nil, fmt.Errorf("%s: unsupported load balancing policy %q", op, cfg.LBPolicy)
 	}
 
-	log.Infof("retry options: %+v", retryOpts)
+	// Проверка готовности должна отвечать быстро, поэтому health check не повторяется
+	if _, ok := retryPolicies.Overrides[healthpb.Health_Check_FullMethodName]; !ok {
+		if retryPolicies.Overrides == nil {
+			retryPolicies.Overrides = make(map[string]RetryPolicy)
+		}
+		healthPolicy := retryPolicies.Default
+		healthPolicy.MaxAttempts = 1
+		retryPolicies.Overrides[healthpb.Health_Check_FullMethodName] = healthPolicy
+	}
 
-	conn, err := grpc.DialContext(ctx, targetAddr, grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithChainUnaryInterceptor(
-			grpclog.UnaryClientInterceptor(log),
-			grpcretry.UnaryClientInterceptor(retryOpts...),
-		))
+	log.Infof("retry policy: %+v, overrides: %+v", retryPolicies.Default, retryPolicies.Overrides)
+
+	addresses := map[string]string{
+		BackendSSO:      cfg.Address,
+		BackendWorkflow: cfg.Address,
+	}
+	if cfg.SSOAddress != "" {
+		addresses[BackendSSO] = cfg.SSOAddress
+	}
+	if cfg.WorkflowAddress != "" {
+		addresses[BackendWorkflow] = cfg.WorkflowAddress
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithResolvers(staticBuilder{}),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, cfg.LBPolicy)),
+		// Спан создается на каждую попытку, поэтому повторы видны в трассе
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: cfg.KeepalivePermitWithoutStream,
+		}))
+	}
+
+	opts = append(opts, extra...)
+
+	b, err := newBreakers(log, cfg)
 	if err != nil {
-		log.WithError(err).Errorf("%s: dial: failed to dial to %s", op, targetAddr)
-		return nil, fmt.Errorf("%s: dial: %w", op, err)
+		return nil, fmt.Errorf("%s: invalid circuit breaker codes: %w", op, err)
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{metadataInterceptor(), grpclog.UnaryClientInterceptor(log)}
+	if b != nil {
+		interceptors = append(interceptors, breakerInterceptor(b))
+	}
+	interceptors = append(interceptors, retryInterceptor(log, retryPolicies))
+
+	byAddress := make(map[string]*grpc.ClientConn, len(addresses))
+	conns := make(map[string]*grpc.ClientConn, len(addresses))
+	for _, backend := range []string{BackendSSO, BackendWorkflow} {
+		address := addresses[backend]
+		if conn, ok := byAddress[address]; ok {
+			conns[backend] = conn
+			continue
+		}
+
+		// Метрики общего соединения помечаются всеми бэкендами, которые его используют
+		label := backend
+		if backend == BackendSSO && addresses[BackendWorkflow] == address {
+			label = BackendSSO + "," + BackendWorkflow
+		}
+
+		target := dialTarget(address)
+		log.WithField("backend", label).WithField("lb_policy", cfg.LBPolicy).Infof("dialing to %s", target)
+
+		conn, err := grpc.DialContext(ctx, target, append(opts,
+			grpc.WithChainUnaryInterceptor(append(interceptors, instanceMetricsInterceptor(label))...))...)
+		if err != nil {
+			log.WithError(err).Errorf("%s: dial: failed to dial to %s", op, target)
+			return nil, fmt.Errorf("%s: dial %s: %w", op, backend, err)
+		}
+
+		byAddress[address] = conn
+		conns[backend] = conn
 	}
 
 	return &Client{
-		AuthService:  ssov1.NewAuthClient(conn),
-		TaskService:  tasksv1.NewTaskServiceClient(conn),
-		CasesService: casesv1.NewCaseServiceClient(conn),
+		AuthService:  ssov1.NewAuthClient(conns[BackendSSO]),
+		TaskService:  tasksv1.NewTaskServiceClient(conns[BackendWorkflow]),
+		CasesService: casesv1.NewCaseServiceClient(conns[BackendWorkflow]),
 		log:          log,
+		conns:        conns,
 	}, nil
 }
+
+// Backends возвращает имена бэкендов, к которым подключен клиент
+func (c *Client) Backends() []string {
+	return []string{BackendSSO, BackendWorkflow}
+}
+
+// State возвращает состояние соединения с бэкендом. Простаивающее
+// соединение при этом начинает подключение, чтобы следующая проверка
+// отражала реальную доступность бэкенда.
+func (c *Client) State(backend string) connectivity.State {
+	conn := c.conns[backend]
+
+	state := conn.GetState()
+	if state == connectivity.Idle {
+		conn.Connect()
+	}
+
+	return state
+}
+
+// CheckHealth опрашивает бэкенд по gRPC health checking protocol.
+// Пустой service означает общее состояние сервера.
+func (c *Client) CheckHealth(ctx context.Context, backend, service string) error {
+	resp, err := healthpb.NewHealthClient(c.conns[backend]).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: service,
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service %q is %s", service, resp.GetStatus())
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataInterceptor передает бэкенду сведения о HTTP запросе из контекста.
+// Стоит первым в цепочке, поэтому их получает каждая повторная попытка.
+func metadataInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if md, ok := reqmeta.FromContext(ctx); ok {
+			if pairs := md.Pairs(); len(pairs) > 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+			}
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	instanceRequests *prometheus.CounterVec
+	instanceDuration *prometheus.HistogramVec
+)
+
+func init() {
+	instanceRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_instance_requests_total",
+		Help: "How many gRPC call attempts were sent, partitioned by backend, backend instance address, method and status code.",
+	}, []string{"backend", "instance", "method", "code"})
+
+	if err := prometheus.Register(instanceRequests); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_instance_requests_total").
+			Error("unable to register prometheus metric")
+	}
+
+	instanceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_client_instance_request_duration_milliseconds",
+		Help:    "Time taken by a gRPC call attempt, partitioned by backend and backend instance address.",
+		Buckets: []float64{5, 20, 50, 100, 500, 1000},
+	}, []string{"backend", "instance"})
+
+	if err := prometheus.Register(instanceDuration); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_instance_request_duration_milliseconds").
+			Error("unable to register prometheus metric")
+	}
+}
+
+// instanceMetricsInterceptor учитывает каждую попытку вызова с адресом экземпляра,
+// который выбрал балансировщик. Подключается после retry, чтобы видеть все попытки.
+func instanceMetricsInterceptor(backend string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var p peer.Peer
+		started := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		instance := "unknown"
+		if p.Addr != nil {
+			instance = p.Addr.String()
+		}
+
+		instanceRequests.WithLabelValues(backend, instance, method, status.Code(err).String()).Inc()
+		instanceDuration.WithLabelValues(backend, instance).Observe(float64(time.Since(started).Milliseconds()))
+
+		return err
+	}
+}
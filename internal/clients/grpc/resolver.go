@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// staticScheme - схема цели для фиксированного списка адресов,
+// например "static:///10.0.0.1:44044,10.0.0.2:44044"
+const staticScheme = "static"
+
+type staticBuilder struct{}
+
+func (staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	var addrs []resolver.Address
+	for _, addr := range strings.Split(target.Endpoint(), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, resolver.Address{Addr: addr})
+		}
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+
+	return staticResolver{}, nil
+}
+
+func (staticBuilder) Scheme() string {
+	return staticScheme
+}
+
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
+
+// dialTarget превращает адрес из конфигурации в цель gRPC. Список адресов через
+// запятую разрешается статически, цели со схемой (например dns:///) передаются как есть.
+func dialTarget(address string) string {
+	if strings.Contains(address, ",") && !strings.Contains(address, "://") {
+		return staticScheme + ":///" + address
+	}
+
+	return address
+}
@@ -0,0 +1,274 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyKeyMetadata - ключ метаданных, по которому бэкенд дедуплицирует
+// повторы мутирующих вызовов
+const IdempotencyKeyMetadata = "idempotency-key"
+
+var (
+	retriesCounter   *prometheus.CounterVec
+	exhaustedCounter *prometheus.CounterVec
+)
+
+func init() {
+	retriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_retries_total",
+		Help: "How many gRPC calls were retried, partitioned by method and status code of the failed attempt.",
+	}, []string{"method", "code"})
+
+	if err := prometheus.Register(retriesCounter); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_retries_total").
+			Error("unable to register prometheus metric")
+	}
+
+	exhaustedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_retries_exhausted_total",
+		Help: "How many gRPC calls failed after using all retry attempts, partitioned by method and status code.",
+	}, []string{"method", "code"})
+
+	if err := prometheus.Register(exhaustedCounter); err != nil {
+		logrus.WithError(err).
+			WithField("metric", "grpc_client_retries_exhausted_total").
+			Error("unable to register prometheus metric")
+	}
+}
+
+// idempotentMethods - чтения, которые можно повторять без ключа идемпотентности.
+// Остальные вызовы, в том числе новые методы бэкенда, повторяются только с ключом.
+var idempotentMethods = map[string]bool{
+	ssov1.Auth_IsAdmin_FullMethodName:                      true,
+	tasksv1.TaskService_GetTask_FullMethodName:             true,
+	tasksv1.TaskService_ListTasks_FullMethodName:           true,
+	tasksv1.TaskService_ListTasksByUserID_FullMethodName:   true,
+	tasksv1.TaskService_ListUsers_FullMethodName:           true,
+	casesv1.CaseService_ListClusters_FullMethodName:        true,
+	casesv1.CaseService_GetCasesFromCluster_FullMethodName: true,
+	healthpb.Health_Check_FullMethodName:                   true,
+}
+
+// RetryPolicy описывает повторы одного вызова. MaxAttempts учитывает первую попытку.
+type RetryPolicy struct {
+	MaxAttempts       uint
+	Codes             []codes.Code
+	Backoff           time.Duration
+	MaxBackoff        time.Duration
+	Jitter            float64
+	PerAttemptTimeout time.Duration
+}
+
+func (p RetryPolicy) retryable(code codes.Code) bool {
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff возвращает экспоненциальную задержку перед попыткой attempt (начиная с 1)
+// со случайным отклонением в пределах Jitter
+func (p RetryPolicy) backoff(attempt uint) time.Duration {
+	delay := p.Backoff << (attempt - 1)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delta := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+
+	return delay
+}
+
+// RetryPolicies выбирает политику для вызова. Чтение использует политику
+// по умолчанию, остальные вызовы повторяются только при наличии ключа идемпотентности.
+// Переопределения допускаются только для чтений.
+type RetryPolicies struct {
+	Default   RetryPolicy
+	Overrides map[string]RetryPolicy
+}
+
+func (p *RetryPolicies) For(ctx context.Context, method string) RetryPolicy {
+	if policy, ok := p.Overrides[method]; ok {
+		return policy
+	}
+
+	policy := p.Default
+	if !idempotentMethods[method] && IdempotencyKey(ctx) == "" {
+		policy.MaxAttempts = 1
+	}
+
+	return policy
+}
+
+// NewRetryPolicies собирает политики повторов из конфигурации клиента
+func NewRetryPolicies(cfg *config.GRPCClient) (*RetryPolicies, error) {
+	const op = "grpc.NewRetryPolicies"
+
+	if cfg.RetriesCount < 1 {
+		return nil, fmt.Errorf("%s: retries count must be at least 1", op)
+	}
+
+	retryCodes, err := ParseCodes(cfg.RetryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid retry codes: %w", op, err)
+	}
+
+	policies := &RetryPolicies{
+		Default: RetryPolicy{
+			MaxAttempts:       uint(cfg.RetriesCount),
+			Codes:             retryCodes,
+			Backoff:           cfg.RetryBackoff,
+			MaxBackoff:        cfg.RetryMaxBackoff,
+			Jitter:            cfg.RetryJitter,
+			PerAttemptTimeout: cfg.Timeout,
+		},
+		Overrides: make(map[string]RetryPolicy, len(cfg.RetryOverrides)),
+	}
+
+	for _, value := range cfg.RetryOverrides {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		method, policy, err := ParseRetryOverride(value, policies.Default)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !idempotentMethods[method] {
+			return nil, fmt.Errorf("%s: retry override for %s is not allowed: only idempotent reads can be overridden", op, method)
+		}
+		policies.Overrides[method] = policy
+	}
+
+	return policies, nil
+}
+
+// ParseRetryOverride разбирает переопределение политики в формате
+// "/tasks.TaskService/ListTasks=5" или "/tasks.TaskService/ListTasks=5:UNAVAILABLE|ABORTED".
+// Незаданные параметры берутся из политики по умолчанию.
+func ParseRetryOverride(value string, defaults RetryPolicy) (string, RetryPolicy, error) {
+	method, spec, ok := strings.Cut(strings.TrimSpace(value), "=")
+	if !ok || !strings.HasPrefix(method, "/") {
+		return "", RetryPolicy{}, fmt.Errorf("invalid retry override %q", value)
+	}
+
+	attempts, codesSpec, _ := strings.Cut(spec, ":")
+	maxAttempts, err := strconv.ParseUint(attempts, 10, 32)
+	if err != nil || maxAttempts == 0 {
+		return "", RetryPolicy{}, fmt.Errorf("invalid max attempts in retry override %q", value)
+	}
+
+	policy := defaults
+	policy.MaxAttempts = uint(maxAttempts)
+	if codesSpec != "" {
+		policy.Codes, err = ParseCodes(strings.Split(codesSpec, "|"))
+		if err != nil {
+			return "", RetryPolicy{}, fmt.Errorf("invalid retry override %q: %w", value, err)
+		}
+	}
+
+	return method, policy, nil
+}
+
+// ParseCodes разбирает имена gRPC кодов в формате UNAVAILABLE, DEADLINE_EXCEEDED
+func ParseCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(strings.TrimSpace(name))))); err != nil {
+			return nil, err
+		}
+		result = append(result, code)
+	}
+
+	return result, nil
+}
+
+// WithIdempotencyKey добавляет ключ идемпотентности в исходящие метаданные.
+// Пустой ключ игнорируется.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, IdempotencyKeyMetadata, key)
+}
+
+func IdempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(IdempotencyKeyMetadata)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func retryInterceptor(log *logrus.Entry, policies *RetryPolicies) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := policies.For(ctx, method)
+
+		var err error
+		for attempt := uint(0); attempt < max(policy.MaxAttempts, 1); attempt++ {
+			if attempt > 0 {
+				code := status.Code(err)
+				retriesCounter.WithLabelValues(method, code.String()).Inc()
+				log.WithField("method", method).WithField("attempt", attempt+1).WithField("code", code.String()).Warn("retrying grpc call")
+
+				timer := time.NewTimer(policy.backoff(attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return status.FromContextError(ctx.Err()).Err()
+				case <-timer.C:
+				}
+			}
+
+			attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+			if policy.PerAttemptTimeout > 0 {
+				attemptCtx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+			}
+			err = invoker(attemptCtx, method, req, reply, cc, opts...)
+			cancel()
+
+			// Отмена исходного контекста означает, что результат уже никому не нужен
+			if err == nil || ctx.Err() != nil || !policy.retryable(status.Code(err)) {
+				return err
+			}
+		}
+
+		if policy.MaxAttempts > 1 {
+			exhaustedCounter.WithLabelValues(method, status.Code(err).String()).Inc()
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,61 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
+)
+
+func TestRetryPoliciesFor(t *testing.T) {
+	policies, err := grpccli.NewRetryPolicies(&config.GRPCClient{RetriesCount: 3, RetryCodes: []string{"UNAVAILABLE"}})
+	if err != nil {
+		t.Fatalf("NewRetryPolicies: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		key          string
+		wantAttempts uint
+	}{
+		{name: "read", method: tasksv1.TaskService_ListTasks_FullMethodName, wantAttempts: 3},
+		{name: "mutation without key", method: tasksv1.TaskService_CreateTask_FullMethodName, wantAttempts: 1},
+		{name: "mutation with key", method: tasksv1.TaskService_CreateTask_FullMethodName, key: "key", wantAttempts: 3},
+		{name: "unknown method", method: "/tasks.TaskService/ArchiveTask", wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := grpccli.WithIdempotencyKey(context.Background(), tt.key)
+
+			if got := policies.For(ctx, tt.method).MaxAttempts; got != tt.wantAttempts {
+				t.Errorf("MaxAttempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestNewRetryPoliciesOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		wantErr  bool
+	}{
+		{name: "read", override: casesv1.CaseService_ListClusters_FullMethodName + "=5"},
+		{name: "mutation", override: casesv1.CaseService_DeleteCase_FullMethodName + "=5", wantErr: true},
+		{name: "unknown method", override: "/cases.CaseService/ArchiveCase=5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := grpccli.NewRetryPolicies(&config.GRPCClient{RetriesCount: 3, RetryOverrides: []string{tt.override}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRetryPolicies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/tlsreload"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// NewTransportCredentials собирает транспортные креды для соединения с бэкендом.
+// Возвращаемый Reloader (nil для insecure режима) нужно запустить, чтобы
+// сертификаты перечитывались при изменении файлов.
+func NewTransportCredentials(log *logrus.Entry, cfg *config.GRPCClient) (credentials.TransportCredentials, *tlsreload.Reloader, error) {
+	const op = "grpc.NewTransportCredentials"
+
+	if cfg.Insecure {
+		if cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" {
+			return nil, nil, fmt.Errorf("%s: tls files are set while insecure mode is enabled", op)
+		}
+
+		log.WithField("operation", op).Warn("using insecure connection to grpc backend")
+		return insecure.NewCredentials(), nil, nil
+	}
+
+	if cfg.TLSReloadInterval <= 0 {
+		return nil, nil, fmt.Errorf("%s: tls reload interval must be positive", op)
+	}
+
+	reloader, err := tlsreload.New(log, cfg.CertFile, cfg.KeyFile, cfg.CAFile, cfg.TLSReloadInterval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if reloader.HasCertificate() {
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	// Собственный CA проверяем вручную, чтобы bundle можно было обновлять на лету.
+	// Без CA используется системное хранилище и стандартная проверка.
+	if reloader.HasCA() {
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if cs.ServerName == "" {
+				return errors.New("tls: server name is not set")
+			}
+			return reloader.VerifyPeer(cs, x509.ExtKeyUsageServerAuth, cs.ServerName)
+		}
+	}
+
+	log.WithField("operation", op).
+		WithField("ca_file", cfg.CAFile).
+		WithField("cert_file", cfg.CertFile).
+		WithField("server_name", cfg.ServerName).
+		Info("using tls connection to grpc backend")
+
+	return credentials.NewTLS(tlsConfig), reloader, nil
+}
@@ -0,0 +1,82 @@
+package clustercache
+
+import (
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/cache"
+	casesv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/cases"
+)
+
+// Cache хранит ответы ListClusters и GetCasesFromCluster. Любое изменение
+// кейсов или кластеров сбрасывает кэш целиком: кейс может переехать между
+// кластерами, а частота кластера зависит от его кейсов. Nil кэш ничего не хранит.
+type Cache struct {
+	clusters *cache.Cache[struct{}, *casesv1.ListClustersResponse]
+	cases    *cache.Cache[int64, *casesv1.GetCasesFromClusterResponse]
+}
+
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		clusters: cache.New[struct{}, *casesv1.ListClustersResponse]("clusters", ttl, 1),
+		cases:    cache.New[int64, *casesv1.GetCasesFromClusterResponse]("cluster_cases", ttl, maxEntries),
+	}
+}
+
+func (c *Cache) Clusters() (*casesv1.ListClustersResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	return c.clusters.Get(struct{}{})
+}
+
+// ClustersGeneration нужно получить до запроса к бэкенду и передать в SetClusters
+func (c *Cache) ClustersGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	return c.clusters.Generation()
+}
+
+func (c *Cache) SetClusters(generation uint64, resp *casesv1.ListClustersResponse) {
+	if c == nil {
+		return
+	}
+
+	c.clusters.Set(generation, struct{}{}, resp)
+}
+
+func (c *Cache) Cases(clusterID int64) (*casesv1.GetCasesFromClusterResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	return c.cases.Get(clusterID)
+}
+
+// CasesGeneration нужно получить до запроса к бэкенду и передать в SetCases
+func (c *Cache) CasesGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	return c.cases.Generation()
+}
+
+func (c *Cache) SetCases(generation uint64, clusterID int64, resp *casesv1.GetCasesFromClusterResponse) {
+	if c == nil {
+		return
+	}
+
+	c.cases.Set(generation, clusterID, resp)
+}
+
+func (c *Cache) Invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.clusters.Purge()
+	c.cases.Purge()
+}
@@ -0,0 +1,8 @@
+package config
+
+import "time"
+
+type APIKeys struct {
+	StorePath string        `env:"REST_SERVER_API_KEYS_PATH" envDefault:"data/api_keys.json"`
+	TokenTTL  time.Duration `env:"REST_SERVER_API_KEY_TOKEN_TTL" envDefault:"5m"`
+}
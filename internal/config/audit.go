@@ -0,0 +1,8 @@
+package config
+
+type Audit struct {
+	Enabled    bool   `env:"REST_SERVER_AUDIT_ENABLED" envDefault:"true"`
+	Path       string `env:"REST_SERVER_AUDIT_PATH" envDefault:"logs/audit.jsonl"`
+	MaxSizeMB  int64  `env:"REST_SERVER_AUDIT_MAX_SIZE_MB" envDefault:"100"`
+	MaxBackups int    `env:"REST_SERVER_AUDIT_MAX_BACKUPS" envDefault:"10"`
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type Auth struct {
+	AppSecret       string        `env:"REST_SERVER_APP_SECRET"`
+	AccessTokenTTL  time.Duration `env:"REST_SERVER_ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"REST_SERVER_REFRESH_TOKEN_TTL" envDefault:"720h"`
+	CookieMode      bool          `env:"REST_SERVER_AUTH_COOKIE_MODE" envDefault:"false"`
+	CookieDomain    string        `env:"REST_SERVER_AUTH_COOKIE_DOMAIN"`
+	CookieSecure    bool          `env:"REST_SERVER_AUTH_COOKIE_SECURE" envDefault:"true"`
+}
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type Cache struct {
+	Enabled    bool          `env:"REST_SERVER_CLUSTER_CACHE_ENABLED" envDefault:"true"`
+	TTL        time.Duration `env:"REST_SERVER_CLUSTER_CACHE_TTL" envDefault:"30s"`
+	MaxEntries int           `env:"REST_SERVER_CLUSTER_CACHE_MAX_ENTRIES" envDefault:"1000"`
+}
@@ -12,9 +12,43 @@ type Config struct {
 	AppID            int32  `env:"REST_SERVER_APP_ID" env-required:"true"`
 	LogsPath         string `env:"REST_SERVER_LOGS_PATH_FILE" env-required:"true"`
 	AnalURL          string `env:"REST_SERVER_ANALYTICS_URL" env-required:"true"`
+	Log              Log
 	HTTPServer       HTTPServer
+	Auth             Auth
+	OIDC             OIDC
+	TwoFactor        TwoFactor
+	Revocation       Revocation
+	RateLimit        RateLimit
+	APIKeys          APIKeys
+	Invitations      Invitations
+	Audit            Audit
+	Tracing          Tracing
+	Cache            Cache
 	Clients          Clients
+	Health           Health
 	PrometheusServer Prometheus
+
+	// MockBackend включается флагом --mock-backend или окружением local
+	MockBackend bool
+}
+
+// redacted заменяет значение секретного поля в логах
+const redacted = "<redacted>"
+
+// Redacted возвращает копию конфигурации без секретов для записи в лог.
+// Заданные секреты заменяются меткой, чтобы было видно, что они установлены.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	cp.Auth.AppSecret = redact(cp.Auth.AppSecret)
+	cp.OIDC.ClientSecret = redact(cp.OIDC.ClientSecret)
+	return &cp
+}
+
+func redact(value string) string {
+	if value == "" {
+		return value
+	}
+	return redacted
 }
 
 func MustLoad() *Config {
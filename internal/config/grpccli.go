@@ -3,8 +3,42 @@ package config
 import "time"
 
 type GRPCClient struct {
+	// Address - адрес, список адресов через запятую или цель со схемой (dns:///host:port)
 	Address      string        `env:"REST_SERVER_GRPC_CLIENT_ADDRESS" env-required:"true"`
-	Timeout      time.Duration `env:"REST_SERVER_GRPC_CLIENT_TIMEOUT"env-required:"true"`
+	Timeout      time.Duration `env:"REST_SERVER_GRPC_CLIENT_TIMEOUT" env-required:"true"`
 	RetriesCount int           `env:"REST_SERVER_GRPC_CLIENT_RETRIES_COUNT" env-required:"true"`
 	Insecure     bool          `env:"REST_SERVER_GRPC_CLIENT_INSECURE" env-default:"false"`
+
+	CAFile            string        `env:"REST_SERVER_GRPC_CLIENT_CA_FILE"`
+	CertFile          string        `env:"REST_SERVER_GRPC_CLIENT_CERT_FILE"`
+	KeyFile           string        `env:"REST_SERVER_GRPC_CLIENT_KEY_FILE"`
+	ServerName        string        `env:"REST_SERVER_GRPC_CLIENT_SERVER_NAME"`
+	TLSReloadInterval time.Duration `env:"REST_SERVER_GRPC_CLIENT_TLS_RELOAD_INTERVAL" envDefault:"10s"`
+
+	// SSOAddress и WorkflowAddress переопределяют Address для отдельных бэкендов
+	SSOAddress      string `env:"REST_SERVER_GRPC_CLIENT_SSO_ADDRESS"`
+	WorkflowAddress string `env:"REST_SERVER_GRPC_CLIENT_WORKFLOW_ADDRESS"`
+	// LBPolicy - pick_first или round_robin
+	LBPolicy string `env:"REST_SERVER_GRPC_CLIENT_LB_POLICY" envDefault:"pick_first"`
+
+	// KeepaliveTime - интервал пингов соединения, 0 выключает keepalive
+	KeepaliveTime                time.Duration `env:"REST_SERVER_GRPC_CLIENT_KEEPALIVE_TIME" envDefault:"0s"`
+	KeepaliveTimeout             time.Duration `env:"REST_SERVER_GRPC_CLIENT_KEEPALIVE_TIMEOUT" envDefault:"20s"`
+	KeepalivePermitWithoutStream bool          `env:"REST_SERVER_GRPC_CLIENT_KEEPALIVE_PERMIT_WITHOUT_STREAM" envDefault:"false"`
+
+	// RetriesCount задает число попыток для чтения и для мутаций с ключом идемпотентности
+	RetryCodes      []string      `env:"REST_SERVER_GRPC_CLIENT_RETRY_CODES" envSeparator:"," envDefault:"UNAVAILABLE,DEADLINE_EXCEEDED,RESOURCE_EXHAUSTED"`
+	RetryBackoff    time.Duration `env:"REST_SERVER_GRPC_CLIENT_RETRY_BACKOFF" envDefault:"100ms"`
+	RetryMaxBackoff time.Duration `env:"REST_SERVER_GRPC_CLIENT_RETRY_MAX_BACKOFF" envDefault:"2s"`
+	RetryJitter     float64       `env:"REST_SERVER_GRPC_CLIENT_RETRY_JITTER" envDefault:"0.2"`
+	// RetryOverrides - политики отдельных методов чтения в формате /tasks.TaskService/ListTasks=5:UNAVAILABLE|ABORTED
+	RetryOverrides []string `env:"REST_SERVER_GRPC_CLIENT_RETRY_OVERRIDES" envSeparator:";"`
+
+	// Автоматы размыкаются после BreakerFailureThreshold неудач подряд с кодами из BreakerCodes
+	BreakerEnabled          bool          `env:"REST_SERVER_GRPC_CLIENT_BREAKER_ENABLED" envDefault:"true"`
+	BreakerCodes            []string      `env:"REST_SERVER_GRPC_CLIENT_BREAKER_CODES" envSeparator:"," envDefault:"UNAVAILABLE,DEADLINE_EXCEEDED,RESOURCE_EXHAUSTED"`
+	BreakerFailureThreshold uint          `env:"REST_SERVER_GRPC_CLIENT_BREAKER_FAILURE_THRESHOLD" envDefault:"5"`
+	BreakerSuccessThreshold uint          `env:"REST_SERVER_GRPC_CLIENT_BREAKER_SUCCESS_THRESHOLD" envDefault:"1"`
+	BreakerOpenTimeout      time.Duration `env:"REST_SERVER_GRPC_CLIENT_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+	BreakerHalfOpenRequests uint          `env:"REST_SERVER_GRPC_CLIENT_BREAKER_HALF_OPEN_REQUESTS" envDefault:"1"`
 }
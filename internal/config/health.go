@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+type Health struct {
+	Timeout time.Duration `env:"REST_SERVER_READINESS_TIMEOUT" envDefault:"2s"`
+	// GRPCService - имя сервиса для gRPC health check, пустое значение проверяет сервер целиком
+	GRPCService     string `env:"REST_SERVER_READINESS_GRPC_SERVICE"`
+	GRPCHealthCheck bool   `env:"REST_SERVER_READINESS_GRPC_HEALTH_CHECK" envDefault:"true"`
+	// AnalyticsURL по умолчанию совпадает с REST_SERVER_ANALYTICS_URL
+	AnalyticsURL string `env:"REST_SERVER_READINESS_ANALYTICS_URL"`
+	// Optional - проверки, недоступность которых не снимает готовность
+	Optional []string `env:"REST_SERVER_READINESS_OPTIONAL" envSeparator:","`
+}
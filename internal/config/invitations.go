@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type Invitations struct {
+	// Required включает регистрацию только по приглашениям
+	Required  bool          `env:"REST_SERVER_INVITE_ONLY" envDefault:"false"`
+	StorePath string        `env:"REST_SERVER_INVITATIONS_PATH" envDefault:"data/invitations.json"`
+	TTL       time.Duration `env:"REST_SERVER_INVITATION_TTL" envDefault:"168h"`
+	// LinkURL - адрес страницы регистрации, к которому добавляется параметр invite
+	LinkURL string `env:"REST_SERVER_INVITATION_LINK_URL"`
+}
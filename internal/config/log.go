@@ -0,0 +1,9 @@
+package config
+
+type Log struct {
+	// Level - debug, info, warn или error. По умолчанию зависит от окружения:
+	// debug для local, info для dev, warn для prod
+	Level string `env:"REST_SERVER_LOG_LEVEL"`
+	// Format - json, text или pretty. По умолчанию pretty для local и json для остальных окружений
+	Format string `env:"REST_SERVER_LOG_FORMAT"`
+}
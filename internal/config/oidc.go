@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type OIDC struct {
+	Enabled       bool     `env:"REST_SERVER_OIDC_ENABLED" envDefault:"false"`
+	Issuer        string   `env:"REST_SERVER_OIDC_ISSUER"`
+	ClientID      string   `env:"REST_SERVER_OIDC_CLIENT_ID"`
+	ClientSecret  string   `env:"REST_SERVER_OIDC_CLIENT_SECRET"`
+	RedirectURL   string   `env:"REST_SERVER_OIDC_REDIRECT_URL"`
+	Scopes        []string `env:"REST_SERVER_OIDC_SCOPES" envSeparator:"," envDefault:"openid,email,profile"`
+	AutoProvision bool     `env:"REST_SERVER_OIDC_AUTO_PROVISION" envDefault:"false"`
+	// AllowedDomains ограничивает автоматическую регистрацию доменами email. Пустой список - без ограничений.
+	AllowedDomains    []string      `env:"REST_SERVER_OIDC_ALLOWED_DOMAINS" envSeparator:","`
+	IdentityStorePath string        `env:"REST_SERVER_OIDC_IDENTITIES_PATH" envDefault:"data/oidc_identities.json"`
+	StateTTL          time.Duration `env:"REST_SERVER_OIDC_STATE_TTL" envDefault:"10m"`
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type RateLimit struct {
+	Enabled     bool          `env:"REST_SERVER_RATE_LIMIT_ENABLED" envDefault:"true"`
+	Default     string        `env:"REST_SERVER_RATE_LIMIT_DEFAULT" envDefault:"600/1m"`
+	Routes      []string      `env:"REST_SERVER_RATE_LIMIT_ROUTES" envSeparator:";" envDefault:"POST /auth/login=10/1m;POST /auth/register=5/1m;POST /auth/bot=10/1m;POST /task/=60/1m"`
+	MaxAttempts int           `env:"REST_SERVER_LOGIN_MAX_ATTEMPTS" envDefault:"5"`
+	BaseLockout time.Duration `env:"REST_SERVER_LOGIN_BASE_LOCKOUT" envDefault:"1m"`
+	MaxLockout  time.Duration `env:"REST_SERVER_LOGIN_MAX_LOCKOUT" envDefault:"1h"`
+}
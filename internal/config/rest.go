@@ -8,4 +8,27 @@ type HTTPServer struct {
 	Timeout     time.Duration `env:"REST_SERVER_TIMEOUT" env-required:"true"`
 	IdleTimeout time.Duration `env:"REST_SERVER_IDLE_TIMEOUT" env-required:"true"`
 	AllowOrigin string        `env:"REST_SERVER_ALLOW_ORIGIN" envDefault:"*"`
+
+	TrustedProxies []string `env:"REST_SERVER_TRUSTED_PROXIES" envSeparator:","`
+	IPRules        []string `env:"REST_SERVER_IP_RULES" envSeparator:";"`
+	// RouteTimeouts переопределяет Timeout для отдельных маршрутов, например "POST /task/=10s"
+	RouteTimeouts []string `env:"REST_SERVER_ROUTE_TIMEOUTS" envSeparator:";"`
+
+	TLS TLS
+}
+
+type TLS struct {
+	Enabled        bool          `env:"REST_SERVER_TLS_ENABLED" envDefault:"false"`
+	CertFile       string        `env:"REST_SERVER_TLS_CERT_FILE"`
+	KeyFile        string        `env:"REST_SERVER_TLS_KEY_FILE"`
+	MinVersion     string        `env:"REST_SERVER_TLS_MIN_VERSION" envDefault:"1.2"`
+	CipherSuites   []string      `env:"REST_SERVER_TLS_CIPHER_SUITES" envSeparator:","`
+	ReloadInterval time.Duration `env:"REST_SERVER_TLS_RELOAD_INTERVAL" envDefault:"10s"`
+
+	RedirectHTTP bool `env:"REST_SERVER_TLS_REDIRECT_HTTP" envDefault:"false"`
+	RedirectPort int  `env:"REST_SERVER_TLS_REDIRECT_PORT" envDefault:"80"`
+
+	HSTS                  bool          `env:"REST_SERVER_HSTS_ENABLED" envDefault:"false"`
+	HSTSMaxAge            time.Duration `env:"REST_SERVER_HSTS_MAX_AGE" envDefault:"8760h"`
+	HSTSIncludeSubdomains bool          `env:"REST_SERVER_HSTS_INCLUDE_SUBDOMAINS" envDefault:"false"`
 }
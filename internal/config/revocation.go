@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+type Revocation struct {
+	// MaxTTL ограничивает время хранения записи, если срок действия токена неизвестен или больше
+	MaxTTL       time.Duration `env:"REST_SERVER_REVOCATION_MAX_TTL" envDefault:"24h"`
+	KafkaBrokers []string      `env:"REST_SERVER_REVOCATION_KAFKA_BROKERS" envSeparator:","`
+	KafkaTopic   string        `env:"REST_SERVER_REVOCATION_KAFKA_TOPIC" envDefault:"gateway.token-revocations"`
+}
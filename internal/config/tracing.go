@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+type Tracing struct {
+	Enabled bool `env:"REST_SERVER_TRACING_ENABLED" envDefault:"false"`
+	// Endpoint - адрес OTLP/gRPC коллектора
+	Endpoint string `env:"REST_SERVER_TRACING_ENDPOINT" envDefault:"localhost:4317"`
+	Insecure bool   `env:"REST_SERVER_TRACING_INSECURE" envDefault:"true"`
+	// SampleRatio - доля новых трасс, которые сохраняются. Решение вызывающего
+	// сервиса из traceparent имеет приоритет.
+	SampleRatio     float64       `env:"REST_SERVER_TRACING_SAMPLE_RATIO" envDefault:"1"`
+	ExportTimeout   time.Duration `env:"REST_SERVER_TRACING_EXPORT_TIMEOUT" envDefault:"10s"`
+	ShutdownTimeout time.Duration `env:"REST_SERVER_TRACING_SHUTDOWN_TIMEOUT" envDefault:"5s"`
+}
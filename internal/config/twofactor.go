@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+type TwoFactor struct {
+	Enabled      bool          `env:"REST_SERVER_2FA_ENABLED" envDefault:"false"`
+	StorePath    string        `env:"REST_SERVER_2FA_PATH" envDefault:"data/two_factor.json"`
+	Issuer       string        `env:"REST_SERVER_2FA_ISSUER" envDefault:"BestHack Support"`
+	ChallengeTTL time.Duration `env:"REST_SERVER_2FA_CHALLENGE_TTL" envDefault:"5m"`
+	MaxAttempts  int           `env:"REST_SERVER_2FA_MAX_ATTEMPTS" envDefault:"5"`
+}
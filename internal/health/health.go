@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc проверяет одну зависимость и возвращает ошибку, если она недоступна
+type CheckFunc func(ctx context.Context) error
+
+type Result struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	optional bool
+}
+
+// Checker выполняет проверки зависимостей параллельно с общим таймаутом.
+// Недоступность необязательной зависимости отражается в отчете,
+// но не переводит сервис в неготовое состояние.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+// Add регистрирует проверку. Вызывается до начала работы.
+func (c *Checker) Add(name string, fn CheckFunc, optional bool) {
+	c.checks = append(c.checks, check{
+		name:     name,
+		fn:       fn,
+		optional: optional,
+	})
+}
+
+// Names возвращает имена зарегистрированных проверок
+func (c *Checker) Names() []string {
+	names := make([]string, 0, len(c.checks))
+	for _, ch := range c.checks {
+		names = append(names, ch.name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(c.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+
+			started := time.Now()
+			err := ch.fn(ctx)
+
+			result := Result{
+				Status:    StatusUp,
+				Optional:  ch.optional,
+				LatencyMS: time.Since(started).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[ch.name] = result
+			if err != nil && !ch.optional {
+				report.Status = StatusDown
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// HTTPCheck считает сервис доступным, если он отвечает на HEAD запрос без ошибки 5xx
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+}
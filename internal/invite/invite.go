@@ -0,0 +1,259 @@
+package invite
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/filestore"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+	// StatusExpired не хранится, а вычисляется для просроченных приглашений в статусе pending
+	StatusExpired = "expired"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid invitation token")
+	ErrNotFound      = errors.New("invitation not found")
+	ErrNotPending    = errors.New("invitation is not pending")
+	ErrExpired       = errors.New("invitation expired")
+	ErrEmailMismatch = errors.New("invitation is issued for another email")
+	ErrInUse         = errors.New("invitation is being accepted")
+)
+
+type Invitation struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	CreatedBy      int64      `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID int64      `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+// State возвращает статус с учетом срока действия
+func (i *Invitation) State(now time.Time) string {
+	if i.Status == StatusPending && now.After(i.ExpiresAt) {
+		return StatusExpired
+	}
+
+	return i.Status
+}
+
+type CreateParams struct {
+	Email     string
+	CreatedBy int64
+	ExpiresAt time.Time
+}
+
+// Store хранит приглашения в JSON файле. Токен приглашения - идентификатор,
+// подписанный секретом приложения, поэтому подделать его без секрета нельзя,
+// а одноразовость обеспечивается статусом приглашения.
+type Store struct {
+	path   string
+	secret []byte
+
+	mu          sync.Mutex
+	invitations map[string]*Invitation
+	// accepting - приглашения, по которым идет регистрация в SSO
+	accepting map[string]bool
+}
+
+func NewStore(path, secret string) (*Store, error) {
+	s := &Store{
+		path:        path,
+		secret:      []byte(secret),
+		invitations: make(map[string]*Invitation),
+		accepting:   make(map[string]bool),
+	}
+
+	var invitations []*Invitation
+	if err := filestore.Load(path, &invitations); err != nil {
+		return nil, err
+	}
+
+	for _, i := range invitations {
+		s.invitations[i.ID] = i
+	}
+
+	return s, nil
+}
+
+// Create создает приглашение и возвращает одноразовый токен для ссылки
+func (s *Store) Create(params CreateParams) (*Invitation, string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+
+	i := &Invitation{
+		ID:        hex.EncodeToString(b),
+		Email:     params.Email,
+		Status:    StatusPending,
+		CreatedBy: params.CreatedBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: params.ExpiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.invitations[i.ID] = i
+	if err := s.save(); err != nil {
+		delete(s.invitations, i.ID)
+		return nil, "", err
+	}
+
+	c := *i
+	return &c, s.sign(i.ID), nil
+}
+
+func (s *Store) List() []*Invitation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitations := make([]*Invitation, 0, len(s.invitations))
+	for _, i := range s.invitations {
+		c := *i
+		invitations = append(invitations, &c)
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+	})
+
+	return invitations
+}
+
+// Revoke отзывает приглашение, которое еще не было принято
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.invitations[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if i.Status == StatusRevoked {
+		return nil
+	}
+
+	if i.Status != StatusPending || s.accepting[id] {
+		return ErrNotPending
+	}
+
+	now := time.Now()
+	i.Status = StatusRevoked
+	i.RevokedAt = &now
+
+	return s.save()
+}
+
+// Reserve проверяет токен и email и блокирует приглашение на время регистрации.
+// После регистрации нужно вызвать Accept, а при ошибке - Release.
+func (s *Store) Reserve(token, email string) (*Invitation, error) {
+	id, ok := s.verify(token)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	switch i.State(time.Now()) {
+	case StatusPending:
+	case StatusExpired:
+		return nil, ErrExpired
+	default:
+		return nil, ErrNotPending
+	}
+
+	if !strings.EqualFold(i.Email, email) {
+		return nil, ErrEmailMismatch
+	}
+
+	if s.accepting[id] {
+		return nil, ErrInUse
+	}
+	s.accepting[id] = true
+
+	c := *i
+	return &c, nil
+}
+
+// Accept отмечает приглашение принятым пользователем userID
+func (s *Store) Accept(id string, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.accepting, id)
+
+	i, ok := s.invitations[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	i.Status = StatusAccepted
+	i.AcceptedAt = &now
+	i.AcceptedUserID = userID
+
+	return s.save()
+}
+
+// Release снимает блокировку, если регистрация не удалась
+func (s *Store) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.accepting, id)
+}
+
+func (s *Store) sign(id string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id))
+
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Store) verify(token string) (string, bool) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+
+	return id, hmac.Equal([]byte(s.sign(id)), []byte(token))
+}
+
+func (s *Store) save() error {
+	invitations := make([]*Invitation, 0, len(s.invitations))
+	for _, i := range s.invitations {
+		invitations = append(invitations, i)
+	}
+
+	if err := filestore.Save(s.path, invitations); err != nil {
+		return fmt.Errorf("save invitations: %w", err)
+	}
+
+	return nil
+}
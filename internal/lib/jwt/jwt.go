@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+)
+
+// Claims повторяет набор полей, которые SSO кладет в access token
+type Claims struct {
+	UserID    int64  `json:"uid"`
+	Email     string `json:"email"`
+	AppID     int32  `json:"app_id"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign подписывает claims алгоритмом HS256 секретом приложения
+func Sign(claims Claims, secret string) (string, error) {
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := encoding.EncodeToString(h) + "." + encoding.EncodeToString(p)
+
+	return unsigned + "." + encoding.EncodeToString(sign(unsigned, secret)), nil
+}
+
+// Parse проверяет подпись и срок действия токена и возвращает его claims
+func Parse(token string, secret string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if !hmac.Equal(signature, sign(parts[0]+"."+parts[1], secret)) {
+		return nil, ErrInvalidSignature
+	}
+
+	claims, err := decodeClaims(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ExpiresAt != 0 && time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// ParseUnverified возвращает claims без проверки подписи.
+// Подходит только для логирования и привязки сессий, но не для авторизации.
+func ParseUnverified(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	return decodeClaims(parts[1])
+}
+
+func decodeClaims(payload string) (*Claims, error) {
+	raw, err := encoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return &claims, nil
+}
+
+func sign(unsigned string, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
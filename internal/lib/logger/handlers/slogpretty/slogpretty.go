@@ -25,6 +25,7 @@ func (opts PrettyHandlerOptions) NewPrettyHandler(
 	out io.Writer,
 ) *PrettyHandler {
 	h := &PrettyHandler{
+		opts:    opts,
 		Handler: slog.NewJSONHandler(out, opts.SlogOpts),
 		l:       stdLog.New(out, "", 0),
 	}
@@ -46,7 +47,11 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		level = color.RedString(level)
 	}
 
-	fields := make(map[string]interface{}, r.NumAttrs())
+	fields := make(map[string]interface{}, r.NumAttrs()+len(h.attrs))
+
+	for _, a := range h.attrs {
+		fields[a.Key] = a.Value.Any()
+	}
 
 	r.Attrs(func(a slog.Attr) bool {
 		fields[a.Key] = a.Value.Any()
@@ -54,10 +59,6 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		return true
 	})
 
-	for _, a := range h.attrs {
-		fields[a.Key] = a.Value.Any()
-	}
-
 	var b []byte
 	var err error
 
@@ -68,7 +69,7 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		}
 	}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
+	timeStr := r.Time.Format("[15:04:05.000]")
 	msg := color.CyanString(r.Message)
 
 	h.l.Println(
@@ -82,17 +83,25 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 }
 
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// Атрибуты накапливаются, иначе логгер из logger.With(...).With(...) теряет первые
+	merged := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	merged = append(merged, attrs...)
+
 	return &PrettyHandler{
-		Handler: h.Handler,
+		opts:    h.opts,
+		Handler: h.Handler.WithAttrs(attrs),
 		l:       h.l,
-		attrs:   attrs,
+		attrs:   merged,
 	}
 }
 
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
 	// TODO: implement
 	return &PrettyHandler{
+		opts:    h.opts,
 		Handler: h.Handler.WithGroup(name),
 		l:       h.l,
+		attrs:   h.attrs,
 	}
 }
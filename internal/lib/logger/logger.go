@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/logger/handlers/slogpretty"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы вывода логов
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatPretty = "pretty"
+)
+
+// Logger - slog логгер приложения и logrus логгер, пишущий через тот же slog.Handler.
+// Logrus нужен коду шлюза и middleware gin и gRPC, которые принимают *logrus.Entry.
+type Logger struct {
+	Slog   *slog.Logger
+	Logrus *logrus.Logger
+}
+
+// New создает логгер с заданным уровнем (debug, info, warn, error) и форматом
+// (json, text, pretty). Записи обоих логгеров получают поле request_id из контекста.
+func New(out io.Writer, level, format string) (*Logger, error) {
+	const op = "logger.New"
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("%s: invalid level %q: %w", op, level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	case FormatPretty:
+		handler = slogpretty.PrettyHandlerOptions{SlogOpts: opts}.NewPrettyHandler(out)
+	default:
+		return nil, fmt.Errorf("%s: unsupported format %q", op, format)
+	}
+
+	// В logrus request_id добавляет reqmeta.LogHook, поэтому мост получает обработчик без contextHandler
+	return &Logger{
+		Slog:   slog.New(contextHandler{handler}),
+		Logrus: NewLogrus(handler, lvl),
+	}, nil
+}
+
+// contextHandler добавляет в запись идентификатор запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := reqmeta.RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(reqmeta.RequestIDKey, id))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"github.com/sirupsen/logrus"
+)
+
+// NewLogrus возвращает logrus логгер, который передает записи в handler.
+// Собственный вывод logrus отключен, формат и место записи определяет handler.
+func NewLogrus(handler slog.Handler, level slog.Level) *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetFormatter(discardFormatter{})
+	log.SetLevel(logrusLevel(level))
+	// Хуки вызываются по порядку, поэтому request_id попадает в запись до отправки в slog
+	log.AddHook(reqmeta.LogHook{})
+	log.AddHook(&slogHook{handler: handler})
+
+	return log
+}
+
+// slogHook пересылает записи logrus в slog.Handler
+type slogHook struct {
+	handler slog.Handler
+}
+
+func (h *slogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *slogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	r := slog.NewRecord(entry.Time, level, entry.Message, 0)
+
+	// Поля logrus хранятся в map, сортировка делает порядок полей в выводе стабильным
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := entry.Data[key].(type) {
+		case error:
+			r.AddAttrs(slog.String(key, value.Error()))
+		default:
+			r.AddAttrs(slog.Any(key, value))
+		}
+	}
+
+	return h.handler.Handle(ctx, r)
+}
+
+// discardFormatter не форматирует записи: их выводит slogHook
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
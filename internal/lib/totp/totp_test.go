@@ -0,0 +1,107 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/totp"
+)
+
+// rfcSecret - ключ "12345678901234567890" из приложения B RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// Ожидаемые значения - последние шесть цифр восьмизначных кодов SHA1 из RFC 6238
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	upper, err := totp.Code(rfcSecret, 1)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	lower, err := totp.Code(strings.ToLower(rfcSecret), 1)
+	if err != nil {
+		t.Fatalf("Code with lowercase secret: %v", err)
+	}
+	if upper != lower {
+		t.Errorf("lowercase secret code = %s, want %s", lower, upper)
+	}
+
+	if _, err := totp.Code("not base32!", 1); err == nil {
+		t.Errorf("Code with malformed secret: want error")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totp.Step(now)
+
+	code := func(offset int64) string {
+		c, err := totp.Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(0), 1, step, true},
+		{"previous step within skew", code(-1), 1, step - 1, true},
+		{"next step within skew", code(1), 1, step + 1, true},
+		{"two steps behind", code(-2), 1, 0, false},
+		{"two steps ahead", code(2), 1, 0, false},
+		{"previous step without skew", code(-1), 0, 0, false},
+		{"wrong length", code(0)[:5], 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := totp.Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK {
+				t.Fatalf("Validate ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && gotStep != tt.wantStep {
+				t.Errorf("Validate step = %d, want %d", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	got := totp.ProvisioningURI("Support", "user@example.com", rfcSecret)
+
+	for _, part := range []string{"otpauth://totp/Support:user@example.com?", "secret=" + rfcSecret, "issuer=Support", "digits=6", "period=30"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("ProvisioningURI = %s, want it to contain %s", got, part)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package mockbackend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Время реакции и решения, которые заглушка сообщает для всех кластеров, в секундах
+const (
+	demoAverageReaction = 300
+	demoAverageDuration = 1800
+)
+
+type clusterRequest struct {
+	Message string `json:"message"`
+}
+
+type clusterResponse struct {
+	ClusterFrequency int     `json:"cluster_frequency"`
+	ClusterIndex     int     `json:"cluster_index"`
+	ClusterName      string  `json:"cluster_name"`
+	AverageDuration  float64 `json:"average_duration"`
+	AverageReaction  float64 `json:"average_reaction"`
+}
+
+// analytics - заглушка сервиса аналитики. Относит обращение к демо-кластеру
+// по ключевым словам и считает частоту обращений в кластере.
+type analytics struct {
+	mu          sync.Mutex
+	frequencies map[int64]int64
+}
+
+func newAnalytics() *analytics {
+	frequencies := make(map[int64]int64, len(demoClusters))
+	for _, cl := range demoClusters {
+		frequencies[cl.id] = cl.frequency
+	}
+
+	return &analytics{frequencies: frequencies}
+}
+
+func (a *analytics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Проверка готовности шлюза обращается к сервису HEAD запросом
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req clusterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cl := classify(req.Message)
+
+	a.mu.Lock()
+	a.frequencies[cl.id]++
+	frequency := a.frequencies[cl.id]
+	a.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(clusterResponse{
+		ClusterFrequency: int(frequency),
+		ClusterIndex:     int(cl.id),
+		ClusterName:      cl.name,
+		AverageDuration:  demoAverageDuration,
+		AverageReaction:  demoAverageReaction,
+	})
+}
+
+func classify(message string) demoCluster {
+	message = strings.ToLower(message)
+	for _, cl := range demoClusters {
+		for _, keyword := range cl.keywords {
+			if strings.Contains(message, keyword) {
+				return cl
+			}
+		}
+	}
+
+	for _, cl := range demoClusters {
+		if cl.id == otherClusterID {
+			return cl
+		}
+	}
+
+	return demoClusters[len(demoClusters)-1]
+}
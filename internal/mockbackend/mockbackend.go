@@ -0,0 +1,117 @@
+// Package mockbackend запускает внутри процесса шлюза заглушки SSO, workflow
+// и аналитики с демо-данными, чтобы шлюз можно было запустить без бэкендов.
+package mockbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/clients/fake"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// Address - адрес gRPC заглушек. Соединение устанавливается через DialOption.
+	Address = "passthrough:///mock-backend"
+
+	bufferSize      = 1 << 20
+	shutdownTimeout = 5 * time.Second
+)
+
+// Backend - gRPC сервер поверх bufconn и HTTP заглушка аналитики
+type Backend struct {
+	log       *logrus.Entry
+	fake      *fake.Backend
+	listener  *bufconn.Listener
+	server    *grpc.Server
+	analytics *http.Server
+	httpLn    net.Listener
+}
+
+// New создает заглушки с демо-данными. Токены подписываются secret, как это делает SSO.
+// Заглушка аналитики слушает случайный локальный порт.
+func New(log *logrus.Entry, secret string, tokenTTL time.Duration) (*Backend, error) {
+	const op = "mockbackend.New"
+
+	httpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to listen analytics stub: %w", op, err)
+	}
+
+	backend := fake.New(secret, tokenTTL)
+	Seed(backend)
+
+	server := grpc.NewServer()
+	backend.RegisterServices(server)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	return &Backend{
+		log:       log.WithField("component", "mock-backend"),
+		fake:      backend,
+		listener:  bufconn.Listen(bufferSize),
+		server:    server,
+		analytics: &http.Server{Handler: newAnalytics(), ReadHeaderTimeout: 5 * time.Second},
+		httpLn:    httpLn,
+	}, nil
+}
+
+// DialOption подключает gRPC клиент шлюза к заглушкам в памяти процесса
+func (b *Backend) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return b.listener.DialContext(ctx)
+	})
+}
+
+// AnalyticsURL возвращает адрес заглушки сервиса аналитики
+func (b *Backend) AnalyticsURL() string {
+	return "http://" + b.httpLn.Addr().String() + "/"
+}
+
+// Start обслуживает запросы до отмены контекста
+func (b *Backend) Start(ctx context.Context) error {
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- b.server.Serve(b.listener)
+	}()
+	go func() {
+		if err := b.analytics.Serve(b.httpLn); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	b.log.WithField("analytics_url", b.AnalyticsURL()).
+		Warnf("mock backend is running, demo users: %s/%s (admin), %s/%s", AdminEmail, AdminPassword, SupportEmail, SupportPassword)
+
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if err != nil {
+			b.stop()
+			return fmt.Errorf("mock backend stopped: %w", err)
+		}
+	}
+
+	b.stop()
+	return nil
+}
+
+func (b *Backend) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := b.analytics.Shutdown(ctx); err != nil {
+		b.log.WithError(err).Error("failed to stop analytics stub")
+	}
+	b.server.GracefulStop()
+}
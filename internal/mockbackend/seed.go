@@ -0,0 +1,95 @@
+package mockbackend
+
+import (
+	"github.com/markgregr/bestHack_support_REST_server/internal/clients/fake"
+)
+
+// Учетные записи демо-данных
+const (
+	AdminEmail      = "admin@example.com"
+	AdminPassword   = "admin"
+	SupportEmail    = "support@example.com"
+	SupportPassword = "support"
+)
+
+type demoCase struct {
+	title    string
+	solution string
+}
+
+type demoCluster struct {
+	id        int64
+	name      string
+	frequency int64
+	// keywords - слова обращения, по которым заглушка аналитики относит его к кластеру
+	keywords []string
+	cases    []demoCase
+	tasks    []demoTask
+}
+
+type demoTask struct {
+	title       string
+	description string
+}
+
+// otherClusterID - кластер для обращений, не подходящих ни под один другой
+const otherClusterID = 4
+
+var demoClusters = []demoCluster{
+	{
+		id:        1,
+		name:      "Доступ к аккаунту",
+		frequency: 12,
+		keywords:  []string{"парол", "вход", "логин", "аккаунт", "password", "login", "account"},
+		cases: []demoCase{
+			{title: "Не приходит письмо для сброса пароля", solution: "Проверить папку спам и адрес почты в профиле, отправить письмо повторно"},
+			{title: "Аккаунт заблокирован", solution: "Подождать 15 минут после неудачных попыток входа или обратиться к администратору"},
+		},
+		tasks: []demoTask{
+			{title: "Не могу войти", description: "После смены пароля не получается войти в аккаунт"},
+		},
+	},
+	{
+		id:        2,
+		name:      "Оплата",
+		frequency: 8,
+		keywords:  []string{"оплат", "плат", "карт", "деньг", "payment", "card", "refund"},
+		cases: []demoCase{
+			{title: "Платеж списан, заказ не оплачен", solution: "Проверить статус платежа в банке, деньги вернутся в течение 3 рабочих дней"},
+		},
+		tasks: []demoTask{
+			{title: "Двойное списание", description: "С карты дважды списали оплату за один заказ"},
+		},
+	},
+	{
+		id:        3,
+		name:      "Доставка",
+		frequency: 5,
+		keywords:  []string{"доставк", "курьер", "заказ", "delivery", "order"},
+		cases: []demoCase{
+			{title: "Курьер не приехал", solution: "Связаться со службой доставки и назначить новый интервал"},
+		},
+	},
+	{
+		id:        otherClusterID,
+		name:      "Другое",
+		frequency: 1,
+	},
+}
+
+// Seed заполняет бэкенд демо-данными: администратором, сотрудником поддержки,
+// кластерами с кейсами и несколькими открытыми задачами
+func Seed(b *fake.Backend) {
+	b.AddUser(AdminEmail, AdminPassword, true)
+	b.AddUser(SupportEmail, SupportPassword, false)
+
+	for _, cl := range demoClusters {
+		b.AddCluster(cl.id, cl.name, cl.frequency)
+		for _, c := range cl.cases {
+			b.AddCase(cl.id, c.title, c.solution)
+		}
+		for _, t := range cl.tasks {
+			b.AddTask(cl.id, t.title, t.description)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/filestore"
+)
+
+// Identity связывает учетную запись IdP с пользователем SSO
+type Identity struct {
+	Issuer   string    `json:"issuer"`
+	Subject  string    `json:"subject"`
+	UserID   int64     `json:"user_id"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// IdentityStore хранит привязки в памяти и сохраняет их в JSON файл
+type IdentityStore struct {
+	path string
+
+	mu         sync.RWMutex
+	identities map[string]*Identity
+}
+
+func NewIdentityStore(path string) (*IdentityStore, error) {
+	s := &IdentityStore{
+		path:       path,
+		identities: make(map[string]*Identity),
+	}
+
+	var identities []*Identity
+	if err := filestore.Load(path, &identities); err != nil {
+		return nil, err
+	}
+
+	for _, i := range identities {
+		s.identities[identityKey(i.Issuer, i.Subject)] = i
+	}
+
+	return s, nil
+}
+
+func (s *IdentityStore) Lookup(issuer, subject string) (*Identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.identities[identityKey(issuer, subject)]
+	if !ok {
+		return nil, false
+	}
+
+	identity := *i
+	return &identity, true
+}
+
+// Link сохраняет привязку, заменяя существующую для той же учетной записи IdP
+func (s *IdentityStore) Link(identity Identity) error {
+	if identity.LinkedAt.IsZero() {
+		identity.LinkedAt = time.Now()
+	}
+
+	key := identityKey(identity.Issuer, identity.Subject)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.identities[key]
+	s.identities[key] = &identity
+	if err := s.save(); err != nil {
+		if prev != nil {
+			s.identities[key] = prev
+		} else {
+			delete(s.identities, key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *IdentityStore) save() error {
+	identities := make([]*Identity, 0, len(s.identities))
+	for _, i := range s.identities {
+		identities = append(identities, i)
+	}
+
+	return filestore.Save(s.path, identities)
+}
+
+func identityKey(issuer, subject string) string {
+	return issuer + "|" + subject
+}
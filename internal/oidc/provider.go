@@ -0,0 +1,421 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// clockSkew допускает расхождение часов шлюза и IdP при проверке сроков токена
+	clockSkew = time.Minute
+	// jwksRefreshInterval ограничивает повторную загрузку ключей при неизвестном kid
+	jwksRefreshInterval = time.Minute
+)
+
+var (
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrExchange       = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrUnknownKey     = errors.New("id token signed with unknown key")
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+// IDToken - проверенные claims ID токена
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Expiry        time.Time
+}
+
+// Provider реализует authorization code flow с PKCE для одного IdP.
+// Discovery документ и ключи подписи загружаются лениво при первом обращении,
+// поэтому недоступность IdP не мешает запуску шлюза.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.RWMutex
+	endpoints   *discovery
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewProvider(cfg Config) *Provider {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+
+	return &Provider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// AuthCodeURL возвращает адрес страницы входа IdP для перенаправления пользователя
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает authorization code на ID токен
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: decode response: %v", ErrExchange, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%w: status %d: %s %s", ErrExchange, resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: response has no id_token", ErrExchange)
+	}
+
+	return body.IDToken, nil
+}
+
+// Verify проверяет подпись, издателя, аудиторию, срок действия и nonce ID токена
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidIDToken, err)
+	}
+
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidIDToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidIDToken, err)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Issuer        string   `json:"iss"`
+		Subject       string   `json:"sub"`
+		Audience      audience `json:"aud"`
+		Expiry        int64    `json:"exp"`
+		Nonce         string   `json:"nonce"`
+		Email         string   `json:"email"`
+		EmailVerified flexBool `json:"email_verified"`
+		Name          string   `json:"name"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Issuer != p.cfg.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	case !claims.Audience.contains(p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: token is not issued for this client", ErrInvalidIDToken)
+	case time.Unix(claims.Expiry, 0).Add(clockSkew).Before(time.Now()):
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: empty subject", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Expiry:        time.Unix(claims.Expiry, 0),
+	}, nil
+}
+
+// Issuer возвращает нормализованный адрес издателя, под которым хранятся привязки
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.RLock()
+	d := p.endpoints
+	p.mu.RUnlock()
+	if d != nil {
+		return d, nil
+	}
+
+	d = &discovery{}
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrDiscovery, d.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete provider metadata", ErrDiscovery)
+	}
+
+	p.mu.Lock()
+	p.endpoints = d
+	p.mu.Unlock()
+
+	return d, nil
+}
+
+// key возвращает ключ подписи по kid. При неизвестном kid ключи загружаются
+// заново, но не чаще jwksRefreshInterval, чтобы ротация ключей у IdP
+// подхватывалась без перезапуска.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	fetched := p.keysFetched
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if !fetched.IsZero() && time.Since(fetched) < jwksRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := p.fetchKeys(ctx, d.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetched = time.Now()
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// audience - claim aud, который по спецификации может быть строкой или массивом
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flexBool принимает email_verified как в виде bool, так и строкой - часть IdP отдает "true"
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v bool
+	if err := json.Unmarshal(data, &v); err == nil {
+		*b = flexBool(v)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b = flexBool(strings.EqualFold(s, "true"))
+
+	return nil
+}
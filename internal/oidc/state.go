@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// maxPending ограничивает число незавершенных входов, чтобы поток запросов
+// на /auth/oidc/login не занимал память без предела
+const maxPending = 10000
+
+var (
+	ErrStateNotFound  = errors.New("oidc state not found or expired")
+	ErrTooManyPending = errors.New("too many pending oidc logins")
+)
+
+// Pending - данные начатого входа, которые нужны при обработке callback
+type Pending struct {
+	State    string
+	Nonce    string
+	Verifier string
+	// LinkUserID задан, если вход начал уже авторизованный пользователь,
+	// и внешняя учетная запись должна быть привязана к нему
+	LinkUserID int64
+	LinkEmail  string
+	// InviteToken - приглашение для регистрации нового пользователя в режиме приглашений
+	InviteToken string
+
+	expiresAt time.Time
+}
+
+// StateStore хранит незавершенные входы в памяти. Каждый state одноразовый.
+type StateStore struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	items map[string]*Pending
+}
+
+func NewStateStore(ttl time.Duration) *StateStore {
+	return &StateStore{
+		ttl:   ttl,
+		items: make(map[string]*Pending),
+	}
+}
+
+// Begin генерирует state, nonce и PKCE verifier и запоминает их
+func (s *StateStore) Begin(linkUserID int64, linkEmail, inviteToken string) (*Pending, error) {
+	state, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pending{
+		State:       state,
+		Nonce:       nonce,
+		Verifier:    verifier,
+		LinkUserID:  linkUserID,
+		LinkEmail:   linkEmail,
+		InviteToken: inviteToken,
+		expiresAt:   time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.items) >= maxPending {
+		s.sweep()
+		if len(s.items) >= maxPending {
+			return nil, ErrTooManyPending
+		}
+	}
+
+	s.items[state] = p
+
+	return p, nil
+}
+
+// Complete возвращает и удаляет данные входа по state
+func (s *StateStore) Complete(state string) (*Pending, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.items[state]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	delete(s.items, state)
+
+	if time.Now().After(p.expiresAt) {
+		return nil, ErrStateNotFound
+	}
+
+	return p, nil
+}
+
+func (s *StateStore) sweep() {
+	now := time.Now()
+	for state, p := range s.items {
+		if now.After(p.expiresAt) {
+			delete(s.items, state)
+		}
+	}
+}
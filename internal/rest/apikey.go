@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/apikey"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+)
+
+// apiKeyScopes - маршруты, доступные по API ключу, и требуемые для них области доступа
+var apiKeyScopes = map[string]string{
+	"POST /task/":             apikey.ScopeTasksCreate,
+	"GET /task/":              apikey.ScopeTasksRead,
+	"GET /task/:taskID":       apikey.ScopeTasksRead,
+	"GET /user/:userID/task":  apikey.ScopeTasksRead,
+	"GET /cluster/":           apikey.ScopeCasesRead,
+	"GET /cluster/:clusterID": apikey.ScopeCasesRead,
+}
+
+// APIKeyAuth возвращает middleware, авторизующий запросы по API ключу.
+// Ключ проверяется локально, после чего шлюз выпускает для владельца ключа
+// короткоживущий access token, который обработчики передают в бэкенд.
+func APIKeyAuth(logger *log.Logger, store *apikey.Store, secret string, appID int32, tokenTTL time.Duration) gin.HandlerFunc {
+	const op = "rest.APIKeyAuth"
+
+	return func(c *gin.Context) {
+		key := helper.ExtractAPIKey(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		log := logger.WithContext(c.Request.Context()).WithField("operation", op)
+
+		k, err := store.Verify(key)
+		if err != nil {
+			log.WithError(err).Warn("api key rejected")
+			response.HandleError(response.NewUnauthorizedError(), c)
+			c.Abort()
+			return
+		}
+
+		scope, ok := apiKeyScopes[c.Request.Method+" "+c.FullPath()]
+		if !ok || !k.HasScope(scope) {
+			log.WithField("key_id", k.ID).WithField("route", c.FullPath()).Warn("api key scope mismatch")
+			response.HandleError(response.NewForbiddenError(), c)
+			c.Abort()
+			return
+		}
+
+		token, err := jwt.Sign(jwt.Claims{
+			UserID:    k.UserID,
+			Email:     k.Email,
+			AppID:     appID,
+			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+		}, secret)
+		if err != nil {
+			log.WithError(err).Error("failed to sign access token for api key")
+			response.HandleError(response.NewInternalError(), c)
+			c.Abort()
+			return
+		}
+
+		c.Set(helper.AccessTokenContextKey, token)
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
+)
+
+// Deadline собирает middleware дедлайнов запросов. Таймаут по умолчанию - REST_SERVER_TIMEOUT,
+// переопределения маршрутов задаются в формате "POST /task/=10s".
+func Deadline(cfg *config.HTTPServer) (gin.HandlerFunc, error) {
+	routes := make(map[string]time.Duration, len(cfg.RouteTimeouts))
+	for _, r := range cfg.RouteTimeouts {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(strings.TrimSpace(r), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q: missing '='", r)
+		}
+
+		method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q: route must be '<METHOD> <PATH>'", r)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %w", r, err)
+		}
+
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = timeout
+	}
+
+	return middleware.Deadline(cfg.Timeout, routes), nil
+}
@@ -0,0 +1,663 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/apikey"
+	"github.com/markgregr/bestHack_support_REST_server/internal/audit"
+	"github.com/markgregr/bestHack_support_REST_server/internal/clients/fake"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/clustercache"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/health"
+	"github.com/markgregr/bestHack_support_REST_server/internal/invite"
+	"github.com/markgregr/bestHack_support_REST_server/internal/oidc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/oidc/oidctest"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/handlers"
+	"github.com/markgregr/bestHack_support_REST_server/internal/revocation"
+	"github.com/markgregr/bestHack_support_REST_server/internal/session"
+	"github.com/markgregr/bestHack_support_REST_server/internal/tracing"
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with actual responses")
+
+const (
+	testSecret = "e2e-secret"
+	testAppID  = 1
+
+	adminEmail      = "admin@example.com"
+	adminPassword   = "admin-password"
+	supportEmail    = "support@example.com"
+	supportPassword = "support-password"
+
+	seedClusterID = 1
+
+	oidcClientID     = "gateway"
+	oidcClientSecret = "gateway-secret"
+	oidcRedirectURL  = "http://gateway.test/auth/oidc/callback"
+
+	userAgent = "e2e-test"
+)
+
+// fixedNow - начало времени фейкового бэкенда: даты задач в ответах не зависят от запуска
+var fixedNow = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Маршруты роутера и маршруты, которые вызвали тесты. TestMain сравнивает их,
+// чтобы новый маршрут не остался без контрактного теста.
+var (
+	registeredRoutes sync.Map
+	calledRoutes     sync.Map
+)
+
+// probeRoutes - маршруты проб, которые не проходят через middlewares шлюза
+var probeRoutes = map[string]struct{}{
+	"GET /healthz": {},
+	"GET /readyz":  {},
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	code := m.Run()
+	if code == 0 && flag.Lookup("test.run").Value.String() == "" {
+		var missing []string
+		registeredRoutes.Range(func(route, _ any) bool {
+			if _, ok := calledRoutes.Load(route); !ok {
+				missing = append(missing, route.(string))
+			}
+			return true
+		})
+
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			fmt.Printf("routes without contract tests:\n  %s\n", strings.Join(missing, "\n  "))
+			code = 1
+		}
+	}
+
+	os.Exit(code)
+}
+
+// testServer - шлюз со всеми обработчиками поверх фейкового бэкенда,
+// подключенного через настоящий gRPC клиент по bufconn
+type testServer struct {
+	router  http.Handler
+	backend *fake.Backend
+	faults  *faultInjector
+	idp     *oidctest.Server
+	logs    *logrustest.Hook
+
+	adminID      int64
+	supportID    int64
+	adminToken   string
+	supportToken string
+	caseID       int64
+	taskID       int64
+}
+
+// newTestServer собирает шлюз с конфигурацией по умолчанию, которую можно изменить опциями
+func newTestServer(t *testing.T, opts ...func(cfg *config.Config)) *testServer {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	// Клиентский gRPC интерцептор пишет успешные вызовы на уровне debug
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(reqmeta.LogHook{})
+
+	s := &testServer{
+		logs:    logrustest.NewLocal(logger),
+		backend: fake.New(testSecret, time.Hour),
+		faults:  &faultInjector{errors: make(map[string]error), metadata: make(map[string]metadata.MD)},
+	}
+	// Часы идут на секунду за каждое обращение, иначе токены двух входов подряд совпадут
+	var ticks time.Duration
+	s.backend.SetClock(func() time.Time {
+		ticks += time.Second
+		return fixedNow.Add(ticks)
+	})
+	s.seed(t)
+
+	apiService := s.startBackend(t, logger)
+	analyticsURL := startAnalytics(t)
+	dir := t.TempDir()
+
+	s.idp = oidctest.NewServer(oidcClientID, oidcClientSecret)
+	t.Cleanup(s.idp.Close)
+
+	var cfg config.Config
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatalf("parse default config: %v", err)
+	}
+	cfg.AppID = testAppID
+	cfg.HTTPServer.Timeout = 5 * time.Second
+	cfg.Auth.AppSecret = testSecret
+	cfg.OIDC = config.OIDC{
+		Enabled:       true,
+		Issuer:        s.idp.Issuer(),
+		ClientID:      oidcClientID,
+		ClientSecret:  oidcClientSecret,
+		RedirectURL:   oidcRedirectURL,
+		Scopes:        []string{"openid", "email", "profile"},
+		AutoProvision: true,
+		StateTTL:      10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	checker := health.NewChecker(time.Second)
+	checker.Add("sso_health", func(ctx context.Context) error {
+		return apiService.CheckHealth(ctx, grpccli.BackendSSO, "")
+	}, false)
+	checker.Add("analytics", health.HTTPCheck(http.DefaultClient, analyticsURL), true)
+
+	revocations := revocation.NewList(time.Hour)
+	sessions := session.NewManager(logger, cfg.Auth.RefreshTokenTTL)
+	sessions.SetRevokeHook(revocations.RevokeHash)
+
+	apiKeys, err := apikey.NewStore(logger, filepath.Join(dir, "api_keys.json"))
+	if err != nil {
+		t.Fatalf("create api key store: %v", err)
+	}
+	twoFactor, err := twofactor.NewStore(filepath.Join(dir, "two_factor.json"), cfg.TwoFactor.Issuer, testSecret)
+	if err != nil {
+		t.Fatalf("create two-factor store: %v", err)
+	}
+	challenges := twofactor.NewChallenges(cfg.TwoFactor.ChallengeTTL, cfg.TwoFactor.MaxAttempts)
+	invites, err := invite.NewStore(filepath.Join(dir, "invitations.json"), testSecret)
+	if err != nil {
+		t.Fatalf("create invitation store: %v", err)
+	}
+	auditLog, err := audit.New(filepath.Join(dir, "audit.jsonl"), 1<<20, 1)
+	if err != nil {
+		t.Fatalf("create audit log: %v", err)
+	}
+	t.Cleanup(func() { _ = auditLog.Close() })
+	identities, err := oidc.NewIdentityStore(filepath.Join(dir, "oidc_identities.json"))
+	if err != nil {
+		t.Fatalf("create identity store: %v", err)
+	}
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       cfg.OIDC.Issuer,
+		ClientID:     cfg.OIDC.ClientID,
+		ClientSecret: cfg.OIDC.ClientSecret,
+		RedirectURL:  cfg.OIDC.RedirectURL,
+		Scopes:       cfg.OIDC.Scopes,
+	})
+	lockout := ratelimit.NewLockout(cfg.RateLimit.MaxAttempts, cfg.RateLimit.BaseLockout, cfg.RateLimit.MaxLockout)
+
+	var requiredInvites *invite.Store
+	if cfg.Invitations.Required {
+		requiredInvites = invites
+	}
+
+	authHandler := handlers.NewAuthHandler(apiService.AuthService, logger, testAppID, &cfg.Auth, sessions, lockout, requiredInvites, twoFactor, challenges, revocations)
+	probes := []handlers.APIHandler{
+		handlers.NewHealthHandler(logger, checker),
+	}
+	apiHandlers := []handlers.APIHandler{
+		authHandler,
+		handlers.NewTaskHandler(apiService.TaskService, logger, testAppID, testSecret, analyticsURL, tracing.HTTPClient(), auditLog),
+		handlers.NewCaseHandler(apiService.CasesService, logger, testAppID, testSecret, auditLog, clustercache.New(time.Minute, 100)),
+		handlers.NewOIDCHandler(logger, authHandler, &cfg.OIDC, provider, identities),
+		handlers.NewAPIKeyHandler(apiService.AuthService, logger, testAppID, testSecret, apiKeys),
+		handlers.NewSessionHandler(logger, testSecret, sessions),
+		handlers.NewTwoFactorHandler(apiService.AuthService, logger, testAppID, testSecret, twoFactor),
+		handlers.NewInvitationHandler(apiService.AuthService, logger, testAppID, testSecret, &cfg.Invitations, invites),
+		handlers.NewAuditHandler(apiService.AuthService, logger, testAppID, testSecret, auditLog),
+	}
+
+	deadline, err := rest.Deadline(&cfg.HTTPServer)
+	if err != nil {
+		t.Fatalf("configure deadlines: %v", err)
+	}
+
+	w := rest.NewWorker(&cfg.HTTPServer, logger, probes, apiHandlers, nil,
+		recordRoute,
+		deadline,
+		rest.APIKeyAuth(logger, apiKeys, testSecret, testAppID, cfg.APIKeys.TokenTTL),
+		middleware.Revocation(logger, revocations),
+	)
+
+	router, err := w.Router()
+	if err != nil {
+		t.Fatalf("build router: %v", err)
+	}
+	for _, route := range router.Routes() {
+		registeredRoutes.Store(route.Method+" "+route.Path, true)
+	}
+
+	s.router = router
+	return s
+}
+
+func (s *testServer) seed(t *testing.T) {
+	t.Helper()
+
+	s.adminID = s.backend.AddUser(adminEmail, adminPassword, true)
+	s.supportID = s.backend.AddUser(supportEmail, supportPassword, false)
+
+	s.backend.AddCluster(seedClusterID, "Доступ к аккаунту", 3)
+	s.caseID = s.backend.AddCase(seedClusterID, "Сброс пароля", "Отправить письмо для сброса пароля")
+	s.taskID = s.backend.AddTask(seedClusterID, "Не могу войти", "После смены пароля не получается войти")
+
+	var err error
+	if s.adminToken, err = s.backend.IssueToken(s.adminID, testAppID); err != nil {
+		t.Fatalf("issue admin token: %v", err)
+	}
+	if s.supportToken, err = s.backend.IssueToken(s.supportID, testAppID); err != nil {
+		t.Fatalf("issue support token: %v", err)
+	}
+}
+
+// startBackend поднимает gRPC сервер фейкового бэкенда и подключает к нему клиент шлюза
+func (s *testServer) startBackend(t *testing.T, logger *logrus.Logger) *grpccli.Client {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.faults.intercept))
+	s.backend.RegisterServices(server)
+	healthpb.RegisterHealthServer(server, grpchealth.NewServer())
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	var cfg config.GRPCClient
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatalf("parse grpc client config: %v", err)
+	}
+	cfg.Address = "passthrough:///bufnet"
+	cfg.RetriesCount = 1
+	cfg.BreakerEnabled = false
+
+	retryPolicies, err := grpccli.NewRetryPolicies(&cfg)
+	if err != nil {
+		t.Fatalf("configure retries: %v", err)
+	}
+
+	client, err := grpccli.New(context.Background(), logrus.NewEntry(logger), &cfg, retryPolicies, insecure.NewCredentials(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatalf("create grpc client: %v", err)
+	}
+
+	return client
+}
+
+// startAnalytics поднимает заглушку аналитики, которая относит любое обращение к seed кластеру.
+// Время реакции больше времени теста, чтобы эскалация не меняла задачи во время проверок.
+func startAnalytics(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(handlers.ClusterResponse{
+			ClusterFrequency: 4,
+			ClusterIndex:     seedClusterID,
+			ClusterName:      "Доступ к аккаунту",
+			AverageDuration:  1800,
+			AverageReaction:  3600,
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func recordRoute(c *gin.Context) {
+	if c.FullPath() != "" {
+		calledRoutes.Store(c.Request.Method+" "+c.FullPath(), true)
+	}
+	c.Next()
+}
+
+// faultInjector подменяет ответ бэкенда ошибкой для выбранных gRPC методов
+// и запоминает метаданные последнего вызова каждого метода
+type faultInjector struct {
+	mu       sync.Mutex
+	errors   map[string]error
+	metadata map[string]metadata.MD
+}
+
+func (f *faultInjector) set(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+func (f *faultInjector) received(method string) metadata.MD {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.metadata[method]
+}
+
+func (f *faultInjector) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	f.mu.Lock()
+	err := f.errors[info.FullMethod]
+	f.metadata[info.FullMethod] = md
+	f.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// call описывает HTTP запрос к шлюзу. body - строка с сырым телом или значение для JSON.
+type call struct {
+	method  string
+	path    string
+	token   string
+	body    any
+	headers map[string]string
+	cookies []*http.Cookie
+}
+
+func (s *testServer) do(t *testing.T, c call) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body io.Reader
+	switch b := c.body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal request body: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(c.method, c.path, body)
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	// Пробы регистрируются до middlewares, поэтому recordRoute их не видит
+	if _, ok := probeRoutes[c.method+" "+req.URL.Path]; ok {
+		calledRoutes.Store(c.method+" "+req.URL.Path, true)
+	}
+
+	return rec
+}
+
+// expect выполняет запрос, проверяет статус и добавляет ответ в golden файл теста
+func (s *testServer) expect(t *testing.T, g *golden, name string, c call, status int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := s.do(t, c)
+	if rec.Code != status {
+		t.Errorf("%s: %s %s: status %d, want %d, body: %s", name, c.method, c.path, rec.Code, status, rec.Body.String())
+	}
+
+	g.add(t, name, s.snapshot(t, c, rec))
+	return rec
+}
+
+type snapshot struct {
+	Request  string            `json:"request"`
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Response any               `json:"response,omitempty"`
+}
+
+// randomSegment - сегмент пути со случайным идентификатором сессии, ключа или приглашения
+var randomSegment = regexp.MustCompile(`/[A-Za-z0-9_-]{16,}`)
+
+// contractHeaders - заголовки ответа, которые входят в контракт API
+var contractHeaders = []string{"Retry-After", "Location"}
+
+func (s *testServer) snapshot(t *testing.T, c call, rec *httptest.ResponseRecorder) snapshot {
+	t.Helper()
+
+	snap := snapshot{
+		Request: c.method + " " + normalizeURL(randomSegment.ReplaceAllString(c.path, "/<id>")),
+		Status:  rec.Code,
+	}
+
+	for _, name := range contractHeaders {
+		if value := rec.Header().Get(name); value != "" {
+			if snap.Headers == nil {
+				snap.Headers = make(map[string]string)
+			}
+			snap.Headers[name] = s.normalizeHeader(name, value)
+		}
+	}
+
+	// Редиректы gin сопровождает HTML телом, в контракт входит только JSON
+	if rec.Body.Len() > 0 && strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		var body any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: response is not json: %q", c.method, c.path, rec.Body.String())
+		}
+		snap.Response = normalize("", body)
+	}
+
+	return snap
+}
+
+func (s *testServer) normalizeHeader(name, value string) string {
+	if name != "Location" {
+		return value
+	}
+
+	return strings.Replace(normalizeURL(value), s.idp.Issuer(), "<issuer>", 1)
+}
+
+// normalizeURL заменяет случайные параметры OIDC в адресе метками
+func normalizeURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.RawQuery == "" {
+		return value
+	}
+
+	q := u.Query()
+	for _, key := range []string{"state", "nonce", "code", "code_challenge"} {
+		if q.Has(key) {
+			q.Set(key, "<random>")
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// volatileKeys - поля со случайными значениями или временем выдачи
+var volatileKeys = map[string]bool{
+	"access_token":     true,
+	"refresh_token":    true,
+	"challenge_token":  true,
+	"csrf_token":       true,
+	"token":            true,
+	"key":              true,
+	"secret":           true,
+	"provisioning_uri": true,
+	"recovery_codes":   true,
+	"expires_in":       true,
+	"latency_ms":       true,
+	"request_id":       true,
+	"link":             true,
+}
+
+var rfc3339 = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+
+// normalize заменяет случайные значения метками, чтобы golden файлы не зависели от запуска.
+// Время фейкового бэкенда детерминировано и остается как есть.
+func normalize(key string, v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, item := range value {
+			value[k] = normalize(k, item)
+		}
+		return value
+	case []any:
+		if volatileKeys[key] && len(value) > 0 {
+			return "<redacted>"
+		}
+		for i, item := range value {
+			value[i] = normalize(key, item)
+		}
+		return value
+	case string:
+		switch {
+		case value == "":
+			return value
+		case volatileKeys[key]:
+			return "<redacted>"
+		case key == "id":
+			return "<id>"
+		case rfc3339.MatchString(value) && !fakeTime(value):
+			return "<time>"
+		}
+		return value
+	case float64:
+		// Длительность бывает нулевой, поэтому числа скрываются всегда
+		if volatileKeys[key] {
+			return "<redacted>"
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// fakeTime проверяет, что время выдано часами фейкового бэкенда, а не шлюзом
+func fakeTime(value string) bool {
+	ts, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false
+	}
+
+	return !ts.Before(fixedNow) && ts.Before(fixedNow.Add(24*time.Hour))
+}
+
+// golden собирает ответы теста и сравнивает их с testdata/golden/<тест>.json.
+// С флагом -update файл перезаписывается.
+type golden struct {
+	path      string
+	snapshots map[string]snapshot
+}
+
+func newGolden(t *testing.T) *golden {
+	t.Helper()
+
+	g := &golden{
+		path:      filepath.Join("testdata", "golden", t.Name()+".json"),
+		snapshots: make(map[string]snapshot),
+	}
+	t.Cleanup(func() { g.verify(t) })
+
+	return g
+}
+
+func (g *golden) add(t *testing.T, name string, snap snapshot) {
+	t.Helper()
+
+	if _, ok := g.snapshots[name]; ok {
+		t.Fatalf("duplicate golden case %q", name)
+	}
+	g.snapshots[name] = snap
+}
+
+func (g *golden) verify(t *testing.T) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(g.snapshots, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal golden: %v", err)
+	}
+	actual = append(actual, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(g.path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(g.path, actual, 0o644); err != nil {
+			t.Fatalf("write golden: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(g.path)
+	if err != nil {
+		t.Fatalf("read golden (run with -update to create): %v", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("response contract changed, run with -update if intended\n--- %s\n%s\n--- actual\n%s", g.path, expected, actual)
+	}
+}
+
+// decode разбирает JSON ответ до нормализации, например чтобы взять идентификатор
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+
+	return v
+}
@@ -0,0 +1,137 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/apikey"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	UserID    int64    `json:"user_id"`
+	Email     string   `json:"email"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+type CreateAPIKeyForm struct {
+	Name      string
+	UserID    int64
+	Email     string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+func NewCreateAPIKeyForm() *CreateAPIKeyForm {
+	return &CreateAPIKeyForm{}
+}
+
+func (f *CreateAPIKeyForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	body, err := io.ReadAll(c.Request.Body)
+	defer c.Request.Body.Close()
+
+	if err != nil {
+		log.WithError(err).Error("unable to read body")
+		return nil, response.NewInternalError()
+	}
+
+	var request *CreateAPIKeyRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request == nil {
+		ve := response.NewValidationError()
+		ve.SetError(response.GeneralErrorKey, response.InvalidRequestStructure, "invalid request structure")
+
+		return nil, ve
+	}
+
+	errors := make(map[string]response.ErrorMessage)
+	f.validateAndSetName(request, errors)
+	f.validateAndSetUserID(request, errors)
+	f.validateAndSetScopes(request, errors)
+	f.validateAndSetExpiresAt(request, errors)
+	f.Email = request.Email
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *CreateAPIKeyForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"name":       f.Name,
+		"user_id":    f.UserID,
+		"email":      f.Email,
+		"scopes":     f.Scopes,
+		"expires_at": f.ExpiresAt,
+	}
+}
+
+func (f *CreateAPIKeyForm) validateAndSetName(request *CreateAPIKeyRequest, errors map[string]response.ErrorMessage) {
+	if request.Name == "" {
+		errors["name"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	f.Name = request.Name
+}
+
+func (f *CreateAPIKeyForm) validateAndSetUserID(request *CreateAPIKeyRequest, errors map[string]response.ErrorMessage) {
+	if request.UserID <= 0 {
+		errors["user_id"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	f.UserID = request.UserID
+}
+
+func (f *CreateAPIKeyForm) validateAndSetScopes(request *CreateAPIKeyRequest, errors map[string]response.ErrorMessage) {
+	if len(request.Scopes) == 0 {
+		errors["scopes"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	for _, scope := range request.Scopes {
+		if !apikey.ValidScope(scope) {
+			errors["scopes"] = response.ErrorMessage{
+				Code:    response.InvalidValue,
+				Message: "unknown scope " + scope,
+			}
+			return
+		}
+	}
+
+	f.Scopes = request.Scopes
+}
+
+func (f *CreateAPIKeyForm) validateAndSetExpiresAt(request *CreateAPIKeyRequest, errors map[string]response.ErrorMessage) {
+	if request.ExpiresAt == "" {
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, request.ExpiresAt)
+	if err != nil || expiresAt.Before(time.Now()) {
+		errors["expires_at"] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected RFC3339 time in the future",
+		}
+		return
+	}
+
+	f.ExpiresAt = &expiresAt
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type ListAuditForm struct {
+	ActorID    int64
+	Resource   string
+	ResourceID int64
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+func NewListAuditForm() *ListAuditForm {
+	return &ListAuditForm{}
+}
+
+// ParseAndValidate разбирает параметры выборки из query string
+func (f *ListAuditForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	errors := make(map[string]response.ErrorMessage)
+
+	f.ActorID = parseID(c, "actor", errors)
+	f.ResourceID = parseID(c, "resource_id", errors)
+	f.Resource = c.Query("resource")
+	f.Action = c.Query("action")
+	f.From = parseTime(c, "from", errors)
+	f.To = parseTime(c, "to", errors)
+	f.validateAndSetLimit(c, errors)
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *ListAuditForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"actor":       f.ActorID,
+		"resource":    f.Resource,
+		"resource_id": f.ResourceID,
+		"action":      f.Action,
+		"from":        f.From,
+		"to":          f.To,
+		"limit":       f.Limit,
+	}
+}
+
+func (f *ListAuditForm) validateAndSetLimit(c *gin.Context, errors map[string]response.ErrorMessage) {
+	value := c.Query("limit")
+	if value == "" {
+		f.Limit = defaultLimit
+		return
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		errors["limit"] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected number from 1 to " + strconv.Itoa(maxLimit),
+		}
+		return
+	}
+
+	f.Limit = limit
+}
+
+func parseID(c *gin.Context, key string, errors map[string]response.ErrorMessage) int64 {
+	value := c.Query(key)
+	if value == "" {
+		return 0
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		errors[key] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected integer",
+		}
+		return 0
+	}
+
+	return id
+}
+
+func parseTime(c *gin.Context, key string, errors map[string]response.ErrorMessage) time.Time {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		errors[key] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected RFC3339 time",
+		}
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+	"io"
+)
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type LoginTwoFactorForm struct {
+	ChallengeToken string
+	Code           string
+}
+
+func NewLoginTwoFactorForm() *LoginTwoFactorForm {
+	return &LoginTwoFactorForm{}
+}
+
+func (f *LoginTwoFactorForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	body, err := io.ReadAll(c.Request.Body)
+	defer c.Request.Body.Close()
+
+	if err != nil {
+		log.WithError(err).Error("unable to read body")
+		return nil, response.NewInternalError()
+	}
+
+	var request *LoginTwoFactorRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request == nil {
+		ve := response.NewValidationError()
+		ve.SetError(response.GeneralErrorKey, response.InvalidRequestStructure, "invalid request structure")
+
+		return nil, ve
+	}
+
+	errors := make(map[string]response.ErrorMessage)
+	f.validateAndSetChallengeToken(request, errors)
+	f.validateAndSetCode(request, errors)
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *LoginTwoFactorForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (f *LoginTwoFactorForm) validateAndSetChallengeToken(request *LoginTwoFactorRequest, errors map[string]response.ErrorMessage) {
+	if request.ChallengeToken == "" {
+		errors["challenge_token"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	f.ChallengeToken = request.ChallengeToken
+}
+
+func (f *LoginTwoFactorForm) validateAndSetCode(request *LoginTwoFactorRequest, errors map[string]response.ErrorMessage) {
+	if request.Code == "" {
+		errors["code"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	f.Code = request.Code
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+	"io"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshForm struct {
+	RefreshToken string
+	FromCookie   bool
+}
+
+func NewRefreshForm() *RefreshForm {
+	return &RefreshForm{}
+}
+
+func (f *RefreshForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	body, err := io.ReadAll(c.Request.Body)
+	defer c.Request.Body.Close()
+
+	if err != nil {
+		log.WithError(err).Error("unable to read body")
+		return nil, response.NewInternalError()
+	}
+
+	// В cookie режиме тело запроса может быть пустым
+	request := &RefreshRequest{}
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &request)
+		if err != nil || request == nil {
+			ve := response.NewValidationError()
+			ve.SetError(response.GeneralErrorKey, response.InvalidRequestStructure, "invalid request structure")
+
+			return nil, ve
+		}
+	}
+
+	errors := make(map[string]response.ErrorMessage)
+	f.validateAndSetRefreshToken(c, request, errors)
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *RefreshForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"from_cookie": f.FromCookie,
+	}
+}
+
+func (f *RefreshForm) validateAndSetRefreshToken(c *gin.Context, request *RefreshRequest, errors map[string]response.ErrorMessage) {
+	if request.RefreshToken != "" {
+		f.RefreshToken = request.RefreshToken
+		return
+	}
+
+	if token, err := c.Cookie(helper.RefreshTokenCookie); err == nil && token != "" {
+		f.RefreshToken = token
+		f.FromCookie = true
+		return
+	}
+
+	errors["refresh_token"] = response.ErrorMessage{
+		Code:    response.MissedValue,
+		Message: "missed value",
+	}
+}
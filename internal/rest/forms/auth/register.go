@@ -10,13 +10,15 @@ import (
 )
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	InviteToken string `json:"invite_token"`
 }
 
 type RegisterForm struct {
-	Email    string
-	Password string
+	Email       string
+	Password    string
+	InviteToken string
 }
 
 func NewRegisterForm() *RegisterForm {
@@ -46,6 +48,7 @@ func (f *RegisterForm) ParseAndValidate(c *gin.Context) (forms.Former, response.
 	errors := make(map[string]response.ErrorMessage)
 	f.validateAndSetEmail(request, errors)
 	f.validateAndSetPassword(request, errors)
+	f.InviteToken = request.InviteToken
 
 	if len(errors) > 0 {
 		return nil, response.NewValidationError(errors)
@@ -0,0 +1,99 @@
+package invitations
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"time"
+)
+
+type CreateInvitationRequest struct {
+	Email     string `json:"email"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type CreateInvitationForm struct {
+	Email     string
+	ExpiresAt *time.Time
+}
+
+func NewCreateInvitationForm() *CreateInvitationForm {
+	return &CreateInvitationForm{}
+}
+
+func (f *CreateInvitationForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	body, err := io.ReadAll(c.Request.Body)
+	defer c.Request.Body.Close()
+
+	if err != nil {
+		log.WithError(err).Error("unable to read body")
+		return nil, response.NewInternalError()
+	}
+
+	var request *CreateInvitationRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request == nil {
+		ve := response.NewValidationError()
+		ve.SetError(response.GeneralErrorKey, response.InvalidRequestStructure, "invalid request structure")
+
+		return nil, ve
+	}
+
+	errors := make(map[string]response.ErrorMessage)
+	f.validateAndSetEmail(request, errors)
+	f.validateAndSetExpiresAt(request, errors)
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *CreateInvitationForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"email":      f.Email,
+		"expires_at": f.ExpiresAt,
+	}
+}
+
+func (f *CreateInvitationForm) validateAndSetEmail(request *CreateInvitationRequest, errors map[string]response.ErrorMessage) {
+	if request.Email == "" {
+		errors["email"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	if !strings.Contains(request.Email, "@") {
+		errors["email"] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected email",
+		}
+		return
+	}
+
+	f.Email = request.Email
+}
+
+func (f *CreateInvitationForm) validateAndSetExpiresAt(request *CreateInvitationRequest, errors map[string]response.ErrorMessage) {
+	if request.ExpiresAt == "" {
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, request.ExpiresAt)
+	if err != nil || expiresAt.Before(time.Now()) {
+		errors["expires_at"] = response.ErrorMessage{
+			Code:    response.InvalidValue,
+			Message: "expected RFC3339 time in the future",
+		}
+		return
+	}
+
+	f.ExpiresAt = &expiresAt
+}
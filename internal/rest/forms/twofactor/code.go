@@ -0,0 +1,66 @@
+package twofactor
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/forms"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+	"io"
+)
+
+type CodeRequest struct {
+	Code string `json:"code"`
+}
+
+type CodeForm struct {
+	Code string
+}
+
+func NewCodeForm() *CodeForm {
+	return &CodeForm{}
+}
+
+func (f *CodeForm) ParseAndValidate(c *gin.Context) (forms.Former, response.Error) {
+	body, err := io.ReadAll(c.Request.Body)
+	defer c.Request.Body.Close()
+
+	if err != nil {
+		log.WithError(err).Error("unable to read body")
+		return nil, response.NewInternalError()
+	}
+
+	var request *CodeRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request == nil {
+		ve := response.NewValidationError()
+		ve.SetError(response.GeneralErrorKey, response.InvalidRequestStructure, "invalid request structure")
+
+		return nil, ve
+	}
+
+	errors := make(map[string]response.ErrorMessage)
+	f.validateAndSetCode(request, errors)
+
+	if len(errors) > 0 {
+		return nil, response.NewValidationError(errors)
+	}
+
+	return f, nil
+}
+
+func (f *CodeForm) ConvertToMap() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (f *CodeForm) validateAndSetCode(request *CodeRequest, errors map[string]response.ErrorMessage) {
+	if request.Code == "" {
+		errors["code"] = response.ErrorMessage{
+			Code:    response.MissedValue,
+			Message: "missed value",
+		}
+		return
+	}
+
+	f.Code = request.Code
+}
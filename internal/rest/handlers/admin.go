@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+)
+
+const adminClaimsKey = "admin_claims"
+
+// adminGuard пропускает к маршруту только администраторов.
+// Пользователь определяется по подписанному access токену, а права проверяются в SSO.
+type adminGuard struct {
+	log    *logrus.Logger
+	api    grpccli.AdminAPI
+	appID  int32
+	secret string
+}
+
+func (g *adminGuard) handle(c *gin.Context) {
+	const op = "handlers.adminGuard.handle"
+	log := g.log.WithContext(c.Request.Context()).WithField("operation", op)
+
+	accessToken := helper.ExtractTokenFromHeaders(c)
+	if accessToken == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := jwt.Parse(accessToken, g.secret)
+	if err != nil {
+		log.WithError(err).Warn("invalid access token")
+		response.HandleError(response.NewUnauthorizedError(), c)
+		c.Abort()
+		return
+	}
+
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", g.appID))
+
+	resp, err := g.api.IsAdmin(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &ssov1.IsAdminRequest{
+		UserId: claims.UserID,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to check admin rights")
+		response.HandleError(response.ResolveError(err), c)
+		c.Abort()
+		return
+	}
+
+	if !resp.GetIsAdmin() {
+		log.WithField("user_id", claims.UserID).Warn("admin rights required")
+		response.HandleError(response.NewForbiddenError(), c)
+		c.Abort()
+		return
+	}
+
+	c.Set(adminClaimsKey, claims)
+	c.Next()
+}
+
+func adminClaims(c *gin.Context) *jwt.Claims {
+	claims, _ := c.Get(adminClaimsKey)
+	if claims == nil {
+		return nil
+	}
+
+	return claims.(*jwt.Claims)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/apikey"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	apikeysform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/apikeys"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type APIKey struct {
+	log   *logrus.Logger
+	store *apikey.Store
+	guard *adminGuard
+}
+
+func NewAPIKeyHandler(api grpccli.AdminAPI, log *logrus.Logger, appID int32, secret string, store *apikey.Store) *APIKey {
+	return &APIKey{
+		log:   log,
+		store: store,
+		guard: &adminGuard{
+			log:    log,
+			api:    api,
+			appID:  appID,
+			secret: secret,
+		},
+	}
+}
+
+func (h *APIKey) EnrichRoutes(router *gin.Engine) {
+	adminRoutes := router.Group("/admin", h.guard.handle)
+	adminRoutes.POST("/api-keys", h.createAPIKeyAction)
+	adminRoutes.GET("/api-keys", h.listAPIKeysAction)
+	adminRoutes.DELETE("/api-keys/:keyID", h.revokeAPIKeyAction)
+}
+
+func (h *APIKey) createAPIKeyAction(c *gin.Context) {
+	const op = "handlers.APIKey.createAPIKeyAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("create api key")
+
+	form, verr := apikeysform.NewCreateAPIKeyForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	key, token, err := h.store.Create(apikey.CreateParams{
+		Name:      form.(*apikeysform.CreateAPIKeyForm).Name,
+		UserID:    form.(*apikeysform.CreateAPIKeyForm).UserID,
+		Email:     form.(*apikeysform.CreateAPIKeyForm).Email,
+		Scopes:    form.(*apikeysform.CreateAPIKeyForm).Scopes,
+		ExpiresAt: form.(*apikeysform.CreateAPIKeyForm).ExpiresAt,
+		CreatedBy: adminClaims(c).UserID,
+	})
+	if err != nil {
+		log.WithError(err).Errorf("%s: failed to create api key", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	resp := apiKeyModel(key)
+	resp.Key = token
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *APIKey) listAPIKeysAction(c *gin.Context) {
+	const op = "handlers.APIKey.listAPIKeysAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("list api keys")
+
+	keysList := make([]models.APIKey, 0)
+	for _, key := range h.store.List() {
+		keysList = append(keysList, apiKeyModel(key))
+	}
+
+	c.JSON(http.StatusOK, keysList)
+}
+
+func (h *APIKey) revokeAPIKeyAction(c *gin.Context) {
+	const op = "handlers.APIKey.revokeAPIKeyAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("revoke api key")
+
+	err := h.store.Revoke(c.Param("keyID"))
+	if err != nil {
+		if errors.Is(err, apikey.ErrKeyNotFound) {
+			response.HandleError(response.NewNotFoundError(), c)
+			return
+		}
+
+		log.WithError(err).Errorf("%s: failed to revoke api key", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyModel(key *apikey.Key) models.APIKey {
+	return models.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		UserID:     key.UserID,
+		Email:      key.Email,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
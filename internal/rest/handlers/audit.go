@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/audit"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	auditform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/audit"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Audit struct {
+	log   *logrus.Logger
+	audit *audit.Log
+	guard *adminGuard
+}
+
+func NewAuditHandler(api grpccli.AdminAPI, log *logrus.Logger, appID int32, secret string, auditLog *audit.Log) *Audit {
+	return &Audit{
+		log:   log,
+		audit: auditLog,
+		guard: &adminGuard{
+			log:    log,
+			api:    api,
+			appID:  appID,
+			secret: secret,
+		},
+	}
+}
+
+func (h *Audit) EnrichRoutes(router *gin.Engine) {
+	router.GET("/audit", h.guard.handle, h.listAuditAction)
+}
+
+func (h *Audit) listAuditAction(c *gin.Context) {
+	const op = "handlers.Audit.listAuditAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("list audit entries")
+
+	form, verr := auditform.NewListAuditForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	entries, err := h.audit.Query(audit.Filter{
+		ActorID:    form.(*auditform.ListAuditForm).ActorID,
+		Resource:   form.(*auditform.ListAuditForm).Resource,
+		ResourceID: form.(*auditform.ListAuditForm).ResourceID,
+		Action:     form.(*auditform.ListAuditForm).Action,
+		From:       form.(*auditform.ListAuditForm).From,
+		To:         form.(*auditform.ListAuditForm).To,
+		Limit:      form.(*auditform.ListAuditForm).Limit,
+	})
+	if err != nil {
+		log.WithError(err).Errorf("%s: failed to query audit log", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	entriesList := make([]models.AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		entriesList = append(entriesList, models.AuditEntry{
+			ID:          e.ID,
+			Time:        e.Time,
+			ActorID:     e.ActorID,
+			ActorEmail:  e.ActorEmail,
+			Action:      e.Action,
+			Resource:    e.Resource,
+			ResourceIDs: e.ResourceIDs,
+			RequestID:   e.RequestID,
+			ClientIP:    e.ClientIP,
+			Before:      e.Before,
+			After:       e.After,
+		})
+	}
+
+	c.JSON(http.StatusOK, entriesList)
+}
+
+// recordAudit дописывает в журнал аудита действие пользователя.
+// Автор действия берется только из токена с проверенной подписью, иначе запись остается без автора.
+// Ошибка записи не прерывает запрос и только логируется.
+func recordAudit(c *gin.Context, log *logrus.Entry, auditLog *audit.Log, secret, action, resource string, ids map[string]int64, before, after interface{}) {
+	if auditLog == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:      action,
+		Resource:    resource,
+		ResourceIDs: ids,
+		RequestID:   helper.GetRequestID(c),
+		Before:      snapshot(before),
+		After:       snapshot(after),
+	}
+
+	if secret != "" {
+		if claims, err := jwt.Parse(helper.ExtractTokenFromHeaders(c), secret); err == nil {
+			entry.ActorID = claims.UserID
+			entry.ActorEmail = claims.Email
+		} else {
+			log.WithError(err).WithField("action", action).Warn("audit actor token is not verified")
+		}
+	}
+
+	if ip := helper.GetRemoteAddr(c.Request); ip != nil {
+		entry.ClientIP = *ip
+	}
+
+	if err := auditLog.Record(entry); err != nil {
+		log.WithError(err).WithField("action", action).Error("failed to write audit entry")
+	}
+}
+
+func snapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
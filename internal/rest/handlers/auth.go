@@ -1,12 +1,21 @@
 package handlers
 
 import (
-	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/invite"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
 	authform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/auth"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/internal/revocation"
+	"github.com/markgregr/bestHack_support_REST_server/internal/session"
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
 	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
@@ -14,19 +23,45 @@ import (
 	"google.golang.org/grpc/metadata"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Auth struct {
-	log   *logrus.Logger
-	api   *grpccli.Client
-	appID int32
+	log      *logrus.Logger
+	api      grpccli.AuthAPI
+	appID    int32
+	cfg      *config.Auth
+	sessions *session.Manager
+	lockout  *ratelimit.Lockout
+	invites  *invite.Store
+
+	twoFactor  *twofactor.Store
+	challenges *twofactor.Challenges
+
+	revocations *revocation.List
 }
 
-func NewAuthHandler(api *grpccli.Client, log *logrus.Logger, appID int32) *Auth {
+// NewAuthHandler создает обработчик авторизации. Если sessions == nil,
+// refresh токены не выдаются и маршрут /auth/refresh не регистрируется.
+// Если lockout == nil, неудачные попытки входа не ограничиваются.
+// Если invites != nil, регистрация возможна только по приглашению.
+// Если twoFactor != nil, пользователи с включенной 2FA входят в два шага.
+// Если revocations != nil, токен при выходе отзывается и на стороне шлюза.
+func NewAuthHandler(api grpccli.AuthAPI, log *logrus.Logger, appID int32, cfg *config.Auth, sessions *session.Manager, lockout *ratelimit.Lockout, invites *invite.Store, twoFactor *twofactor.Store, challenges *twofactor.Challenges, revocations *revocation.List) *Auth {
 	return &Auth{
-		log:   log,
-		api:   api,
-		appID: appID,
+		log:      log,
+		api:      api,
+		appID:    appID,
+		cfg:      cfg,
+		sessions: sessions,
+		lockout:  lockout,
+		invites:  invites,
+
+		twoFactor:  twoFactor,
+		challenges: challenges,
+
+		revocations: revocations,
 	}
 }
 
@@ -36,11 +71,17 @@ func (h *Auth) EnrichRoutes(router *gin.Engine) {
 	authRoutes.POST("/login", h.loginAction)
 	authRoutes.POST("/logout", h.logoutAction)
 	authRoutes.POST("/bot", h.botAuthAction)
+	if h.sessions != nil {
+		authRoutes.POST("/refresh", h.refreshAction)
+	}
+	if h.twoFactor != nil {
+		authRoutes.POST("/login/2fa", h.loginTwoFactorAction)
+	}
 }
 
 func (h *Auth) registerAction(c *gin.Context) {
 	const op = "handlers.Auth.registerAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("register user")
 
 	form, verr := authform.NewRegisterForm().ParseAndValidate(c)
@@ -49,16 +90,37 @@ func (h *Auth) registerAction(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.api.AuthService.Register(c, &ssov1.RegisterRequest{
+	var invitation *invite.Invitation
+	if h.invites != nil {
+		var err error
+		invitation, err = h.invites.Reserve(form.(*authform.RegisterForm).InviteToken, form.(*authform.RegisterForm).Email)
+		if err != nil {
+			log.WithError(err).Warn("registration without valid invitation")
+			response.HandleError(response.NewInvalidInvitationError(), c)
+			return
+		}
+	}
+
+	resp, err := h.api.Register(c.Request.Context(), &ssov1.RegisterRequest{
 		Email:    form.(*authform.RegisterForm).Email,
 		Password: form.(*authform.RegisterForm).Password,
 	})
 	if err != nil {
+		if invitation != nil {
+			h.invites.Release(invitation.ID)
+		}
 		log.WithError(err).Errorf("%s: failed to register user", op)
 		response.HandleError(response.ResolveError(err), c)
 		return
 	}
 
+	if invitation != nil {
+		// Пользователь уже создан в SSO, поэтому ошибка сохранения статуса только логируется
+		if err := h.invites.Accept(invitation.ID, resp.UserId); err != nil {
+			log.WithError(err).WithField("invitation", invitation.ID).Error("failed to mark invitation accepted")
+		}
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"userId": resp.UserId,
 	})
@@ -66,7 +128,7 @@ func (h *Auth) registerAction(c *gin.Context) {
 
 func (h *Auth) loginAction(c *gin.Context) {
 	const op = "handlers.Auth.loginAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("login user")
 
 	form, verr := authform.NewLoginForm().ParseAndValidate(c)
@@ -75,20 +137,124 @@ func (h *Auth) loginAction(c *gin.Context) {
 		return
 	}
 
-	token, err := h.api.AuthService.Login(c, &ssov1.LoginRequest{
+	email := strings.ToLower(form.(*authform.LoginForm).Email)
+	if h.lockout != nil {
+		if wait := h.lockout.Check(email); wait > 0 {
+			log.WithField("email", email).Warn("login is locked out")
+			response.HandleError(response.NewTooManyRequestsError(wait), c)
+			return
+		}
+	}
+
+	token, err := h.api.Login(c.Request.Context(), &ssov1.LoginRequest{
 		Email:    form.(*authform.LoginForm).Email,
 		Password: form.(*authform.LoginForm).Password,
 		AppId:    h.appID,
 	})
 	if err != nil {
 		log.WithError(err).Error("failed to login user")
-		response.HandleError(response.ResolveError(err), c)
+		rerr := response.ResolveError(err)
+		if _, ok := rerr.(*response.InvalidCredentialsError); ok && h.lockout != nil {
+			if lockout := h.lockout.Fail(email); lockout > 0 {
+				log.WithField("email", email).WithField("lockout", lockout).Warn("too many failed login attempts")
+			}
+		}
+		response.HandleError(rerr, c)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.AuthToken{
-		AccessToken: token.GetToken(),
+	if h.lockout != nil {
+		h.lockout.Reset(email)
+	}
+
+	if h.twoFactor != nil {
+		// Токен получен напрямую от SSO, поэтому подпись здесь не проверяем
+		claims, err := jwt.ParseUnverified(token.GetToken())
+		if err != nil {
+			log.WithError(err).Error("failed to parse access token")
+			response.HandleError(response.NewInternalError(), c)
+			return
+		}
+
+		if h.twoFactor.Enabled(claims.UserID) {
+			h.respondWithChallenge(c, log, claims, token.GetToken())
+			return
+		}
+	}
+
+	h.completeLogin(c, log, token.GetToken())
+}
+
+func (h *Auth) loginTwoFactorAction(c *gin.Context) {
+	const op = "handlers.Auth.loginTwoFactorAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("login user second factor")
+
+	form, verr := authform.NewLoginTwoFactorForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	challenge, err := h.challenges.Complete(form.(*authform.LoginTwoFactorForm).ChallengeToken, func(challenge *twofactor.Challenge) error {
+		err := h.twoFactor.Verify(challenge.UserID, form.(*authform.LoginTwoFactorForm).Code)
+		if errors.Is(err, twofactor.ErrInvalidCode) && h.lockout != nil {
+			h.lockout.Fail(strings.ToLower(challenge.Email))
+		}
+		return err
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			log.Warn("invalid two-factor code")
+			response.HandleError(response.NewInvalidTwoFactorCodeError(), c)
+		case errors.Is(err, twofactor.ErrChallengeNotFound), errors.Is(err, twofactor.ErrNotEnrolled):
+			log.WithError(err).Warn("invalid two-factor challenge")
+			response.HandleError(response.NewUnauthorizedError(), c)
+		default:
+			log.WithError(err).Error("failed to verify two-factor code")
+			response.HandleError(response.NewInternalError(), c)
+		}
+		return
+	}
+
+	h.completeLogin(c, log, challenge.AccessToken)
+}
+
+func (h *Auth) refreshAction(c *gin.Context) {
+	const op = "handlers.Auth.refreshAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("refresh token")
+
+	form, verr := authform.NewRefreshForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	var (
+		accessToken string
+		expiresAt   int64
+	)
+	_, refreshToken, err := h.sessions.Rotate(form.(*authform.RefreshForm).RefreshToken, func(s *session.Session) (string, error) {
+		var err error
+		accessToken, expiresAt, err = h.issueAccessToken(s.UserID, s.Email)
+		return accessToken, err
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) || errors.Is(err, session.ErrTokenReused) {
+			log.WithError(err).Warn("failed to refresh token")
+			h.clearAuthCookies(c)
+			response.HandleError(response.NewInvalidRefreshTokenError(), c)
+			return
+		}
+
+		log.WithError(err).Error("failed to refresh token")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	h.respondWithTokens(c, accessToken, refreshToken, expiresAt)
 }
 
 func (h *Auth) logoutAction(c *gin.Context) {
@@ -98,20 +264,32 @@ func (h *Auth) logoutAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
-	_, err := h.api.AuthService.Logout(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &emptypb.Empty{})
+	_, err := h.api.Logout(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &emptypb.Empty{})
 	if err != nil {
 		response.HandleError(response.ResolveError(err), c)
 		return
 	}
 
+	if h.revocations != nil {
+		h.revocations.Revoke(accessToken)
+	}
+
+	if h.sessions != nil {
+		h.sessions.RevokeByAccessToken(accessToken)
+		if refreshToken, err := c.Cookie(helper.RefreshTokenCookie); err == nil && refreshToken != "" {
+			h.sessions.RevokeByRefreshToken(refreshToken)
+		}
+	}
+
+	h.clearAuthCookies(c)
 	c.Status(http.StatusNoContent)
 }
 
 func (h *Auth) botAuthAction(c *gin.Context) {
 	const op = "handlers.Auth.botAuthAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("bot auth")
 
 	form, verr := authform.NewBotAuthForm().ParseAndValidate(c)
@@ -120,7 +298,21 @@ func (h *Auth) botAuthAction(c *gin.Context) {
 		return
 	}
 
-	_, err := h.api.AuthService.BotAuth(c, &ssov1.BotAuthRequest{
+	// BotAuth регистрирует неизвестного пользователя, поэтому в режиме приглашений
+	// бот может войти только в существующую учетную запись
+	if h.invites != nil {
+		if _, err := h.api.Login(c.Request.Context(), &ssov1.LoginRequest{
+			Email:    form.(*authform.BotAuthForm).Email,
+			Password: form.(*authform.BotAuthForm).Password,
+			AppId:    h.appID,
+		}); err != nil {
+			log.WithError(err).Warn("bot auth without existing account")
+			response.HandleError(response.ResolveError(err), c)
+			return
+		}
+	}
+
+	_, err := h.api.BotAuth(c.Request.Context(), &ssov1.BotAuthRequest{
 		Email:    form.(*authform.BotAuthForm).Email,
 		Password: form.(*authform.BotAuthForm).Password,
 		Username: form.(*authform.BotAuthForm).Username,
@@ -134,3 +326,137 @@ func (h *Auth) botAuthAction(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// completeLogin выдает клиенту токен SSO, а при включенных сессиях - еще и refresh токен
+func (h *Auth) completeLogin(c *gin.Context, log *logrus.Entry, accessToken string) {
+	if h.sessions == nil {
+		c.JSON(http.StatusOK, models.AuthToken{
+			AccessToken: accessToken,
+		})
+		return
+	}
+
+	// Токен получен напрямую от SSO, поэтому подпись здесь не проверяем
+	claims, err := jwt.ParseUnverified(accessToken)
+	if err != nil {
+		log.WithError(err).Error("failed to parse access token")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	_, refreshToken, err := h.sessions.Create(h.sessionMeta(c, claims), accessToken)
+	if err != nil {
+		log.WithError(err).Error("failed to create session")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	h.respondWithTokens(c, accessToken, refreshToken, claims.ExpiresAt)
+}
+
+// respondWithChallenge откладывает выдачу токена до проверки второго фактора
+func (h *Auth) respondWithChallenge(c *gin.Context, log *logrus.Entry, claims *jwt.Claims, accessToken string) {
+	challengeToken, expiresAt, err := h.challenges.Issue(twofactor.Challenge{
+		UserID:      claims.UserID,
+		Email:       claims.Email,
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to issue two-factor challenge")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    challengeToken,
+		ExpiresIn:         int64(time.Until(expiresAt).Seconds()),
+	})
+}
+
+// issueAccessToken выпускает access токен от имени SSO, подписанный секретом приложения
+func (h *Auth) issueAccessToken(userID int64, email string) (string, int64, error) {
+	expiresAt := time.Now().Add(h.cfg.AccessTokenTTL).Unix()
+	token, err := jwt.Sign(jwt.Claims{
+		UserID:    userID,
+		Email:     email,
+		AppID:     h.appID,
+		ExpiresAt: expiresAt,
+	}, h.cfg.AppSecret)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (h *Auth) sessionMeta(c *gin.Context, claims *jwt.Claims) session.Meta {
+	meta := session.Meta{
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	if ip := helper.GetRemoteAddr(c.Request); ip != nil {
+		meta.IP = *ip
+	}
+
+	return meta
+}
+
+// respondWithTokens отдает токены в теле ответа, а в cookie режиме -
+// в HttpOnly cookie вместе с CSRF токеном для double-submit проверки
+func (h *Auth) respondWithTokens(c *gin.Context, accessToken, refreshToken string, expiresAt int64) {
+	var expiresIn int64
+	if expiresAt > 0 {
+		expiresIn = expiresAt - time.Now().Unix()
+	}
+
+	if !h.cfg.CookieMode {
+		c.JSON(http.StatusOK, models.AuthToken{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresIn:    expiresIn,
+		})
+		return
+	}
+
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		h.log.WithError(err).Error("failed to generate csrf token")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	refreshMaxAge := int(h.cfg.RefreshTokenTTL.Seconds())
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(helper.AccessTokenCookie, accessToken, int(expiresIn), "/", h.cfg.CookieDomain, h.cfg.CookieSecure, true)
+	c.SetCookie(helper.RefreshTokenCookie, refreshToken, refreshMaxAge, "/auth", h.cfg.CookieDomain, h.cfg.CookieSecure, true)
+	c.SetCookie(helper.CSRFTokenCookie, csrfToken, refreshMaxAge, "/", h.cfg.CookieDomain, h.cfg.CookieSecure, false)
+
+	c.JSON(http.StatusOK, models.AuthToken{
+		CSRFToken: csrfToken,
+		ExpiresIn: expiresIn,
+	})
+}
+
+func (h *Auth) clearAuthCookies(c *gin.Context) {
+	if !h.cfg.CookieMode {
+		return
+	}
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(helper.AccessTokenCookie, "", -1, "/", h.cfg.CookieDomain, h.cfg.CookieSecure, true)
+	c.SetCookie(helper.RefreshTokenCookie, "", -1, "/auth", h.cfg.CookieDomain, h.cfg.CookieSecure, true)
+	c.SetCookie(helper.CSRFTokenCookie, "", -1, "/", h.cfg.CookieDomain, h.cfg.CookieSecure, false)
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
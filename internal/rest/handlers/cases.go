@@ -1,11 +1,13 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/markgregr/bestHack_support_REST_server/internal/audit"
 	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/clustercache"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
 	casesform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/cases"
 	clusterform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/cluster"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
@@ -19,19 +21,36 @@ import (
 )
 
 type Case struct {
-	log   *logrus.Entry
-	api   *grpccli.Client
-	appID int32
+	log    *logrus.Entry
+	api    grpccli.CasesAPI
+	appID  int32
+	secret string
+	audit  *audit.Log
+	cache  *clustercache.Cache
 }
 
-func NewCaseHandler(api *grpccli.Client, log *logrus.Logger, appID int32) *Case {
+func NewCaseHandler(api grpccli.CasesAPI, log *logrus.Logger, appID int32, secret string, auditLog *audit.Log, clusterCache *clustercache.Cache) *Case {
 	return &Case{
-		log:   logrus.NewEntry(log),
-		api:   api,
-		appID: appID,
+		log:    logrus.NewEntry(log),
+		api:    api,
+		appID:  appID,
+		secret: secret,
+		audit:  auditLog,
+		cache:  clusterCache,
 	}
 }
 
+// cacheAllowed разрешает ответ из кэша только для валидного токена: при промахе
+// токен проверяет бэкенд, а при попадании - сам шлюз
+func (h *Case) cacheAllowed(accessToken string) bool {
+	if h.cache == nil {
+		return false
+	}
+
+	_, err := jwt.Parse(accessToken, h.secret)
+	return err == nil
+}
+
 func (h *Case) EnrichRoutes(router *gin.Engine) {
 	clusterRoutes := router.Group("/cluster")
 	clusterRoutes.GET("/", h.listClustersAction)
@@ -45,7 +64,7 @@ func (h *Case) EnrichRoutes(router *gin.Engine) {
 
 func (h *Case) createCaseAction(c *gin.Context) {
 	const op = "handlers.Case.createCaseAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("create case")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -54,7 +73,8 @@ func (h *Case) createCaseAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	clusterID, err := strconv.ParseInt(c.Param("clusterID"), 10, 64)
 	if err != nil {
@@ -69,7 +89,7 @@ func (h *Case) createCaseAction(c *gin.Context) {
 		return
 	}
 
-	caseItem, err := h.api.CasesService.CreateCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.CreateCaseRequest{
+	caseItem, err := h.api.CreateCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.CreateCaseRequest{
 		Title:     form.(*casesform.CreateCaseForm).Title,
 		Solution:  form.(*casesform.CreateCaseForm).Solution,
 		ClusterId: clusterID,
@@ -80,7 +100,7 @@ func (h *Case) createCaseAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.Case{
+	resp := models.Case{
 		ID:       caseItem.Id,
 		Title:    caseItem.Title,
 		Solution: caseItem.Solution,
@@ -89,12 +109,17 @@ func (h *Case) createCaseAction(c *gin.Context) {
 			Name:      caseItem.Cluster.Name,
 			Frequency: caseItem.Cluster.Frequency,
 		},
-	})
+	}
+
+	h.cache.Invalidate()
+	recordAudit(c, log, h.audit, h.secret, "case.create", "case", map[string]int64{"case_id": caseItem.Id, "cluster_id": clusterID}, nil, resp)
+
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *Case) listCasesFromClusterAction(c *gin.Context) {
 	const op = "handlers.Case.getClusterAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("get cluster")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -103,7 +128,7 @@ func (h *Case) listCasesFromClusterAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
 	clusterID, err := strconv.ParseInt(c.Param("clusterID"), 10, 64)
 	if err != nil {
@@ -112,13 +137,24 @@ func (h *Case) listCasesFromClusterAction(c *gin.Context) {
 		return
 	}
 
-	cluster, err := h.api.CasesService.GetCasesFromCluster(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.GetCasesFromClusterRequest{
-		Id: clusterID,
-	})
-	if err != nil {
-		log.WithError(err).Errorf("%s: failed to get cluster", op)
-		response.HandleError(response.ResolveError(err), c)
-		return
+	var (
+		cluster *casesv1.GetCasesFromClusterResponse
+		cached  bool
+	)
+	if h.cacheAllowed(accessToken) {
+		cluster, cached = h.cache.Cases(clusterID)
+	}
+	if !cached {
+		generation := h.cache.CasesGeneration()
+		cluster, err = h.api.GetCasesFromCluster(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.GetCasesFromClusterRequest{
+			Id: clusterID,
+		})
+		if err != nil {
+			log.WithError(err).Errorf("%s: failed to get cluster", op)
+			response.HandleError(response.ResolveError(err), c)
+			return
+		}
+		h.cache.SetCases(generation, clusterID, cluster)
 	}
 
 	var casesList []*models.Case
@@ -140,7 +176,7 @@ func (h *Case) listCasesFromClusterAction(c *gin.Context) {
 
 func (h *Case) listClustersAction(c *gin.Context) {
 	const op = "handlers.Case.listClustersAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("list clusters")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -149,13 +185,25 @@ func (h *Case) listClustersAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
-	clusters, err := h.api.CasesService.ListClusters(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &empty.Empty{})
-	if err != nil {
-		log.WithError(err).Errorf("%s: failed to list clusters", op)
-		response.HandleError(response.ResolveError(err), c)
-		return
+	var (
+		clusters *casesv1.ListClustersResponse
+		cached   bool
+	)
+	if h.cacheAllowed(accessToken) {
+		clusters, cached = h.cache.Clusters()
+	}
+	if !cached {
+		generation := h.cache.ClustersGeneration()
+		var err error
+		clusters, err = h.api.ListClusters(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &empty.Empty{})
+		if err != nil {
+			log.WithError(err).Errorf("%s: failed to list clusters", op)
+			response.HandleError(response.ResolveError(err), c)
+			return
+		}
+		h.cache.SetClusters(generation, clusters)
 	}
 
 	var clustersList []*models.Cluster
@@ -172,7 +220,7 @@ func (h *Case) listClustersAction(c *gin.Context) {
 
 func (h *Case) updateCaseAction(c *gin.Context) {
 	const op = "handlers.Case.updateCaseAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("update case")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -181,7 +229,8 @@ func (h *Case) updateCaseAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	caseID, err := strconv.ParseInt(c.Param("caseID"), 10, 64)
 	if err != nil {
@@ -196,7 +245,9 @@ func (h *Case) updateCaseAction(c *gin.Context) {
 		return
 	}
 
-	caseItem, err := h.api.CasesService.UpdateCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.UpdateCaseRequest{
+	before := h.caseAuditState(caseID)
+
+	caseItem, err := h.api.UpdateCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.UpdateCaseRequest{
 		Id:       caseID,
 		Title:    &form.(*casesform.UpdateCaseForm).Title,
 		Solution: &form.(*casesform.UpdateCaseForm).Solution,
@@ -207,7 +258,7 @@ func (h *Case) updateCaseAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Case{
+	resp := models.Case{
 		ID:       caseItem.Id,
 		Title:    caseItem.Title,
 		Solution: caseItem.Solution,
@@ -216,12 +267,17 @@ func (h *Case) updateCaseAction(c *gin.Context) {
 			Name:      caseItem.Cluster.Name,
 			Frequency: caseItem.Cluster.Frequency,
 		},
-	})
+	}
+
+	h.cache.Invalidate()
+	recordAudit(c, log, h.audit, h.secret, "case.update", "case", map[string]int64{"case_id": caseID}, before, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Case) deleteCaseAction(c *gin.Context) {
 	const op = "handlers.Case.deleteCaseAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("delete case")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -230,7 +286,8 @@ func (h *Case) deleteCaseAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	caseID, err := strconv.ParseInt(c.Param("caseID"), 10, 64)
 	if err != nil {
@@ -239,7 +296,9 @@ func (h *Case) deleteCaseAction(c *gin.Context) {
 		return
 	}
 
-	_, err = h.api.CasesService.DeleteCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.DeleteCaseRequest{
+	before := h.caseAuditState(caseID)
+
+	_, err = h.api.DeleteCase(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.DeleteCaseRequest{
 		Id: caseID,
 	})
 	if err != nil {
@@ -248,12 +307,15 @@ func (h *Case) deleteCaseAction(c *gin.Context) {
 		return
 	}
 
+	h.cache.Invalidate()
+	recordAudit(c, log, h.audit, h.secret, "case.delete", "case", map[string]int64{"case_id": caseID}, before, nil)
+
 	c.Status(http.StatusNoContent)
 }
 
 func (h *Case) updateClusterNameAction(c *gin.Context) {
 	const op = "handlers.Case.updateClusterNameAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("update cluster name")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -262,7 +324,8 @@ func (h *Case) updateClusterNameAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	clusterID, err := strconv.ParseInt(c.Param("clusterID"), 10, 64)
 	if err != nil {
@@ -277,7 +340,9 @@ func (h *Case) updateClusterNameAction(c *gin.Context) {
 		return
 	}
 
-	cluster, err := h.api.CasesService.UpdateClusterName(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.UpdateClusterNameRequest{
+	before := h.clusterAuditState(clusterID)
+
+	cluster, err := h.api.UpdateClusterName(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &casesv1.UpdateClusterNameRequest{
 		Id:   clusterID,
 		Name: form.(*clusterform.UpdateClusterForm).Name,
 	})
@@ -287,9 +352,82 @@ func (h *Case) updateClusterNameAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Cluster{
+	resp := models.Cluster{
 		ID:        cluster.Id,
 		Name:      cluster.Name,
 		Frequency: cluster.Frequency,
-	})
+	}
+
+	h.cache.Invalidate()
+	recordAudit(c, log, h.audit, h.secret, "cluster.rename", "cluster", map[string]int64{"cluster_id": clusterID}, before, resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// caseAuditState возвращает кейс до изменения для журнала аудита из кэша кластеров.
+// Бэкенд не вызывается, чтобы аудит не добавлял запросов к каждому изменению,
+// поэтому при промахе кэша состояние до изменения не записывается.
+func (h *Case) caseAuditState(caseID int64) interface{} {
+	if h.audit == nil {
+		return nil
+	}
+
+	clusters, ok := h.cache.Clusters()
+	if !ok {
+		return nil
+	}
+
+	for _, cluster := range clusters.Clusters {
+		cases, ok := h.cache.Cases(cluster.Id)
+		if !ok {
+			continue
+		}
+
+		for _, caseItem := range cases.Cases {
+			if caseItem.Id != caseID {
+				continue
+			}
+
+			state := models.Case{
+				ID:       caseItem.Id,
+				Title:    caseItem.Title,
+				Solution: caseItem.Solution,
+			}
+			if caseItem.Cluster != nil {
+				state.Cluster = &models.Cluster{
+					ID:        caseItem.Cluster.Id,
+					Name:      caseItem.Cluster.Name,
+					Frequency: caseItem.Cluster.Frequency,
+				}
+			}
+
+			return state
+		}
+	}
+
+	return nil
+}
+
+// clusterAuditState возвращает кластер до переименования из кэша кластеров или nil при промахе
+func (h *Case) clusterAuditState(clusterID int64) interface{} {
+	if h.audit == nil {
+		return nil
+	}
+
+	clusters, ok := h.cache.Clusters()
+	if !ok {
+		return nil
+	}
+
+	for _, cluster := range clusters.Clusters {
+		if cluster.Id == clusterID {
+			return models.Cluster{
+				ID:        cluster.Id,
+				Name:      cluster.Name,
+				Frequency: cluster.Frequency,
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/health"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Health struct {
+	log     *logrus.Logger
+	checker *health.Checker
+}
+
+func NewHealthHandler(log *logrus.Logger, checker *health.Checker) *Health {
+	return &Health{
+		log:     log,
+		checker: checker,
+	}
+}
+
+// EnrichRoutes регистрирует пробы. Worker вызывает его до подключения middlewares
+// шлюза, чтобы пробы не ограничивались rate limit, фильтром IP и проверкой отзыва токенов
+func (h *Health) EnrichRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.livenessAction)
+	router.GET("/readyz", h.readinessAction)
+}
+
+// livenessAction сообщает только о том, что процесс обслуживает запросы,
+// чтобы недоступность бэкенда не приводила к перезапуску шлюза
+func (h *Health) livenessAction(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+func (h *Health) readinessAction(c *gin.Context) {
+	const op = "handlers.Health.readinessAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+
+	report := h.checker.Check(c.Request.Context())
+
+	readiness := models.Readiness{
+		Status:       report.Status,
+		Dependencies: make(map[string]models.DependencyStatus, len(report.Checks)),
+	}
+	for name, result := range report.Checks {
+		readiness.Dependencies[name] = models.DependencyStatus{
+			Status:   result.Status,
+			Optional: result.Optional,
+		}
+		if result.Status != health.StatusUp {
+			log.WithField("dependency", name).WithField("error", result.Error).WithField("latency_ms", result.LatencyMS).Warn("dependency is not ready")
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, readiness)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/invite"
+	invitationsform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/invitations"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Invitation struct {
+	log   *logrus.Logger
+	cfg   *config.Invitations
+	store *invite.Store
+	guard *adminGuard
+}
+
+func NewInvitationHandler(api grpccli.AdminAPI, log *logrus.Logger, appID int32, secret string, cfg *config.Invitations, store *invite.Store) *Invitation {
+	return &Invitation{
+		log:   log,
+		cfg:   cfg,
+		store: store,
+		guard: &adminGuard{
+			log:    log,
+			api:    api,
+			appID:  appID,
+			secret: secret,
+		},
+	}
+}
+
+func (h *Invitation) EnrichRoutes(router *gin.Engine) {
+	adminRoutes := router.Group("/admin", h.guard.handle)
+	adminRoutes.POST("/invitations", h.createInvitationAction)
+	adminRoutes.GET("/invitations", h.listInvitationsAction)
+	adminRoutes.DELETE("/invitations/:inviteID", h.revokeInvitationAction)
+}
+
+func (h *Invitation) createInvitationAction(c *gin.Context) {
+	const op = "handlers.Invitation.createInvitationAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("create invitation")
+
+	form, verr := invitationsform.NewCreateInvitationForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	expiresAt := time.Now().Add(h.cfg.TTL)
+	if form.(*invitationsform.CreateInvitationForm).ExpiresAt != nil {
+		expiresAt = *form.(*invitationsform.CreateInvitationForm).ExpiresAt
+	}
+
+	invitation, token, err := h.store.Create(invite.CreateParams{
+		Email:     form.(*invitationsform.CreateInvitationForm).Email,
+		CreatedBy: adminClaims(c).UserID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.WithError(err).Errorf("%s: failed to create invitation", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	resp := invitationModel(invitation)
+	resp.Token = token
+	resp.Link = h.link(token)
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *Invitation) listInvitationsAction(c *gin.Context) {
+	const op = "handlers.Invitation.listInvitationsAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("list invitations")
+
+	status := c.Query("status")
+
+	invitationsList := make([]models.Invitation, 0)
+	for _, invitation := range h.store.List() {
+		item := invitationModel(invitation)
+		if status != "" && item.Status != status {
+			continue
+		}
+		invitationsList = append(invitationsList, item)
+	}
+
+	c.JSON(http.StatusOK, invitationsList)
+}
+
+func (h *Invitation) revokeInvitationAction(c *gin.Context) {
+	const op = "handlers.Invitation.revokeInvitationAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("revoke invitation")
+
+	err := h.store.Revoke(c.Param("inviteID"))
+	if err != nil {
+		switch {
+		case errors.Is(err, invite.ErrNotFound):
+			response.HandleError(response.NewNotFoundError(), c)
+		case errors.Is(err, invite.ErrNotPending):
+			response.HandleError(response.NewInvalidInvitationError(), c)
+		default:
+			log.WithError(err).Errorf("%s: failed to revoke invitation", op)
+			response.HandleError(response.NewInternalError(), c)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// link собирает ссылку на страницу регистрации. Без настроенного адреса отдается только токен.
+func (h *Invitation) link(token string) string {
+	if h.cfg.LinkURL == "" {
+		return ""
+	}
+
+	u, err := url.Parse(h.cfg.LinkURL)
+	if err != nil {
+		h.log.WithError(err).Warn("invalid invitation link url")
+		return ""
+	}
+
+	q := u.Query()
+	q.Set("invite", token)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func invitationModel(invitation *invite.Invitation) models.Invitation {
+	return models.Invitation{
+		ID:             invitation.ID,
+		Email:          invitation.Email,
+		Status:         invitation.State(time.Now()),
+		CreatedBy:      invitation.CreatedBy,
+		CreatedAt:      invitation.CreatedAt,
+		ExpiresAt:      invitation.ExpiresAt,
+		AcceptedAt:     invitation.AcceptedAt,
+		AcceptedUserID: invitation.AcceptedUserID,
+		RevokedAt:      invitation.RevokedAt,
+	}
+}
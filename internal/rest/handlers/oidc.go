@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/invite"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/internal/oidc"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	ssov1 "github.com/markgregr/bestHack_support_protos/gen/go/sso"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+// oidcStateCookie привязывает начатый вход к браузеру, чтобы callback
+// с чужим state нельзя было подставить пользователю
+const oidcStateCookie = "oidc_state"
+
+type OIDC struct {
+	log        *logrus.Logger
+	auth       *Auth
+	cfg        *config.OIDC
+	provider   *oidc.Provider
+	states     *oidc.StateStore
+	identities *oidc.IdentityStore
+}
+
+// NewOIDCHandler создает обработчик входа через корпоративный IdP.
+// Токены выпускаются так же, как при refresh, поэтому auth должен
+// быть создан с секретом приложения и менеджером сессий.
+func NewOIDCHandler(log *logrus.Logger, auth *Auth, cfg *config.OIDC, provider *oidc.Provider, identities *oidc.IdentityStore) *OIDC {
+	return &OIDC{
+		log:        log,
+		auth:       auth,
+		cfg:        cfg,
+		provider:   provider,
+		states:     oidc.NewStateStore(cfg.StateTTL),
+		identities: identities,
+	}
+}
+
+func (h *OIDC) EnrichRoutes(router *gin.Engine) {
+	oidcRoutes := router.Group("/auth/oidc")
+	oidcRoutes.GET("/login", h.loginAction)
+	oidcRoutes.GET("/callback", h.callbackAction)
+}
+
+func (h *OIDC) loginAction(c *gin.Context) {
+	const op = "handlers.OIDC.loginAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("start oidc login")
+
+	// Авторизованный пользователь привязывает учетную запись IdP к себе
+	var (
+		linkUserID int64
+		linkEmail  string
+	)
+	if accessToken := helper.ExtractTokenFromHeaders(c); accessToken != "" {
+		if claims, err := jwt.Parse(accessToken, h.auth.cfg.AppSecret); err == nil {
+			linkUserID, linkEmail = claims.UserID, claims.Email
+		}
+	}
+
+	// Приглашение передается параметром invite ссылки так же, как при регистрации по паролю
+	pending, err := h.states.Begin(linkUserID, linkEmail, c.Query("invite"))
+	if err != nil {
+		log.WithError(err).Error("failed to start oidc login")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	authURL, err := h.provider.AuthCodeURL(c.Request.Context(), pending.State, pending.Nonce, pending.Verifier)
+	if err != nil {
+		log.WithError(err).Error("failed to build authorization url")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	// Lax, а не Strict: callback приходит переходом со страницы IdP
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, pending.State, int(h.cfg.StateTTL.Seconds()), "/auth/oidc", h.auth.cfg.CookieDomain, h.auth.cfg.CookieSecure, true)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (h *OIDC) callbackAction(c *gin.Context) {
+	const op = "handlers.OIDC.callbackAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("oidc callback")
+
+	state := c.Query("state")
+	cookieState, _ := c.Cookie(oidcStateCookie)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", h.auth.cfg.CookieDomain, h.auth.cfg.CookieSecure, true)
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		log.Warn("oidc state does not match cookie")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	pending, err := h.states.Complete(state)
+	if err != nil {
+		log.WithError(err).Warn("unknown oidc state")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	if idpError := c.Query("error"); idpError != "" {
+		log.WithField("error", idpError).WithField("description", c.Query("error_description")).Warn("idp rejected login")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		log.Warn("oidc callback without code")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	rawIDToken, err := h.provider.Exchange(c.Request.Context(), code, pending.Verifier)
+	if err != nil {
+		log.WithError(err).Error("failed to exchange authorization code")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	idToken, err := h.provider.Verify(c.Request.Context(), rawIDToken, pending.Nonce)
+	if err != nil {
+		log.WithError(err).Error("failed to verify id token")
+		response.HandleError(response.NewSSOLoginError(), c)
+		return
+	}
+
+	identity, rerr := h.resolveIdentity(c, log, pending, idToken)
+	if rerr != nil {
+		response.HandleError(rerr, c)
+		return
+	}
+
+	accessToken, expiresAt, err := h.auth.issueAccessToken(identity.UserID, identity.Email)
+	if err != nil {
+		log.WithError(err).Error("failed to issue access token")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	_, refreshToken, err := h.auth.sessions.Create(h.auth.sessionMeta(c, &jwt.Claims{
+		UserID: identity.UserID,
+		Email:  identity.Email,
+	}), accessToken)
+	if err != nil {
+		log.WithError(err).Error("failed to create session")
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	h.auth.respondWithTokens(c, accessToken, refreshToken, expiresAt)
+}
+
+// resolveIdentity находит пользователя SSO для учетной записи IdP.
+// Новая учетная запись привязывается к пользователю, начавшему вход,
+// либо, если это разрешено, для нее регистрируется пользователь в SSO.
+func (h *OIDC) resolveIdentity(c *gin.Context, log *logrus.Entry, pending *oidc.Pending, idToken *oidc.IDToken) (*oidc.Identity, response.Error) {
+	log = log.WithField("subject", idToken.Subject)
+
+	if identity, ok := h.identities.Lookup(idToken.Issuer, idToken.Subject); ok {
+		if pending.LinkUserID != 0 && pending.LinkUserID != identity.UserID {
+			log.WithField("user_id", pending.LinkUserID).Warn("identity is already linked to another user")
+			return nil, response.NewForbiddenError()
+		}
+
+		return identity, nil
+	}
+
+	identity := oidc.Identity{
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+	}
+
+	switch {
+	case pending.LinkUserID != 0:
+		identity.UserID, identity.Email = pending.LinkUserID, pending.LinkEmail
+		log.WithField("user_id", identity.UserID).Info("linking identity to user")
+	case !h.cfg.AutoProvision:
+		log.Warn("identity is not linked and auto provisioning is disabled")
+		return nil, response.NewIdentityNotLinkedError()
+	case idToken.Email == "" || !idToken.EmailVerified:
+		log.Warn("identity has no verified email to provision user")
+		return nil, response.NewIdentityNotLinkedError()
+	case !h.domainAllowed(idToken.Email):
+		log.WithField("email", idToken.Email).Warn("email domain is not allowed for auto provisioning")
+		return nil, response.NewIdentityNotLinkedError()
+	default:
+		userID, rerr := h.provision(c, log, idToken.Email, pending.InviteToken)
+		if rerr != nil {
+			return nil, rerr
+		}
+		identity.UserID, identity.Email = userID, idToken.Email
+	}
+
+	if err := h.identities.Link(identity); err != nil {
+		log.WithError(err).Error("failed to save identity link")
+		return nil, response.NewInternalError()
+	}
+
+	return &identity, nil
+}
+
+// provision регистрирует пользователя в SSO со случайным паролем:
+// пользователь входит только через IdP, поэтому пароль нигде не сохраняется.
+// В режиме приглашений нужно приглашение на email из IdP.
+func (h *OIDC) provision(c *gin.Context, log *logrus.Entry, email, inviteToken string) (int64, response.Error) {
+	var invitation *invite.Invitation
+	if h.auth.invites != nil {
+		var err error
+		invitation, err = h.auth.invites.Reserve(inviteToken, email)
+		if err != nil {
+			log.WithError(err).WithField("email", email).Warn("provisioning without valid invitation")
+			return 0, response.NewInvalidInvitationError()
+		}
+	}
+
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		log.WithError(err).Error("failed to generate password")
+		return 0, response.NewInternalError()
+	}
+
+	resp, err := h.auth.api.Register(c.Request.Context(), &ssov1.RegisterRequest{
+		Email:    email,
+		Password: base64.RawURLEncoding.EncodeToString(password),
+	})
+	if err != nil {
+		if invitation != nil {
+			h.auth.invites.Release(invitation.ID)
+		}
+
+		rerr := response.ResolveError(err)
+		if _, ok := rerr.(*response.UserExistError); ok {
+			// Существующую учетную запись нужно привязать, войдя в нее по паролю
+			log.WithField("email", email).Warn("user already exists, identity must be linked manually")
+			return 0, response.NewIdentityNotLinkedError()
+		}
+
+		log.WithError(err).Error("failed to provision user")
+		return 0, rerr
+	}
+
+	if invitation != nil {
+		// Пользователь уже создан в SSO, поэтому ошибка сохранения статуса только логируется
+		if err := h.auth.invites.Accept(invitation.ID, resp.GetUserId()); err != nil {
+			log.WithError(err).WithField("invitation", invitation.ID).Error("failed to mark invitation accepted")
+		}
+	}
+
+	log.WithField("user_id", resp.GetUserId()).Info("user provisioned from identity provider")
+
+	return resp.GetUserId(), nil
+}
+
+func (h *OIDC) domainAllowed(email string) bool {
+	if len(h.cfg.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domain := email[at+1:]
+	for _, allowed := range h.cfg.AllowedDomains {
+		if strings.EqualFold(strings.TrimSpace(allowed), domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/internal/session"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type Session struct {
+	log      *logrus.Logger
+	sessions *session.Manager
+	guard    *userGuard
+}
+
+func NewSessionHandler(log *logrus.Logger, secret string, sessions *session.Manager) *Session {
+	return &Session{
+		log:      log,
+		sessions: sessions,
+		guard: &userGuard{
+			log:    log,
+			secret: secret,
+		},
+	}
+}
+
+func (h *Session) EnrichRoutes(router *gin.Engine) {
+	sessionRoutes := router.Group("/auth/sessions", h.guard.handle)
+	sessionRoutes.GET("", h.listSessionsAction)
+	sessionRoutes.DELETE("", h.revokeOtherSessionsAction)
+	sessionRoutes.DELETE("/:sessionID", h.revokeSessionAction)
+}
+
+func (h *Session) listSessionsAction(c *gin.Context) {
+	const op = "handlers.Session.listSessionsAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("list sessions")
+
+	current := h.sessions.IDByAccessToken(helper.ExtractTokenFromHeaders(c))
+
+	sessionsList := make([]models.Session, 0)
+	for _, s := range h.sessions.List(userClaims(c).UserID) {
+		sessionsList = append(sessionsList, models.Session{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			Current:    s.ID == current,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, sessionsList)
+}
+
+func (h *Session) revokeSessionAction(c *gin.Context) {
+	const op = "handlers.Session.revokeSessionAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("revoke session")
+
+	err := h.sessions.Revoke(userClaims(c).UserID, c.Param("sessionID"))
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			response.HandleError(response.NewNotFoundError(), c)
+			return
+		}
+
+		log.WithError(err).Errorf("%s: failed to revoke session", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// revokeOtherSessionsAction завершает все сессии пользователя, кроме текущей
+func (h *Session) revokeOtherSessionsAction(c *gin.Context) {
+	const op = "handlers.Session.revokeOtherSessionsAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("revoke other sessions")
+
+	current := h.sessions.IDByAccessToken(helper.ExtractTokenFromHeaders(c))
+	revoked := h.sessions.RevokeAll(userClaims(c).UserID, current)
+
+	log.WithField("count", revoked).Info("sessions revoked")
+
+	c.Status(http.StatusNoContent)
+}
@@ -7,13 +7,18 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/markgregr/bestHack_support_REST_server/internal/audit"
 	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
 	tasksform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/tasks"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/internal/tracing"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
 	tasksv1 "github.com/markgregr/bestHack_support_protos/gen/go/workflow/tasks"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/metadata"
 	"io/ioutil"
 	"net/http"
@@ -22,18 +27,24 @@ import (
 )
 
 type Task struct {
-	log     *logrus.Logger
-	api     *grpccli.Client
-	appID   int32
-	analURL string
+	log        *logrus.Logger
+	api        grpccli.TaskAPI
+	appID      int32
+	analURL    string
+	analClient *http.Client
+	secret     string
+	audit      *audit.Log
 }
 
-func NewTaskHandler(api *grpccli.Client, log *logrus.Logger, appID int32, analURL string) *Task {
+func NewTaskHandler(api grpccli.TaskAPI, log *logrus.Logger, appID int32, secret string, analURL string, analClient *http.Client, auditLog *audit.Log) *Task {
 	return &Task{
-		log:     log,
-		api:     api,
-		appID:   appID,
-		analURL: analURL,
+		log:        log,
+		api:        api,
+		appID:      appID,
+		analURL:    analURL,
+		analClient: analClient,
+		secret:     secret,
+		audit:      auditLog,
 	}
 }
 
@@ -66,9 +77,12 @@ type ClusterResponse struct {
 
 const delay = 60
 
+// escalationCallTimeout - запас времени на вызовы бэкенда сверх ожидания эскалации
+const escalationCallTimeout = 30 * time.Second
+
 func (h *Task) createTaskAction(c *gin.Context) {
 	const op = "handlers.Task.createTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("create task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -77,7 +91,7 @@ func (h *Task) createTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
 	form, verr := tasksform.NewCreateTaskForm().ParseAndValidate(c)
 	if verr != nil {
@@ -94,12 +108,20 @@ func (h *Task) createTaskAction(c *gin.Context) {
 		return
 	}
 
-	resp, err := http.Post(h.analURL, "application/json", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.analURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		log.WithError(err).Errorf("%s: failed to send request to anal", op)
+		log.WithError(err).Errorf("%s: failed to build request to anal", op)
 		response.HandleError(response.NewInternalError(), c)
 		return
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.analClient.Do(req)
+	if err != nil {
+		log.WithError(err).Errorf("%s: failed to send request to anal", op)
+		response.HandleError(response.ResolveError(err), c)
+		return
+	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -118,7 +140,9 @@ func (h *Task) createTaskAction(c *gin.Context) {
 	}
 
 	log.Error(clusterResp)
-	task, err := h.api.TaskService.CreateTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.CreateTaskRequest{
+	// Ключ идемпотентности относится только к созданию задачи, а не к последующей эскалации
+	createCtx := grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
+	task, err := h.api.CreateTask(metadata.AppendToOutgoingContext(createCtx, "access_token", accessToken), &tasksv1.CreateTaskRequest{
 		Title:           form.(*tasksform.CreateTaskForm).Title,
 		Description:     form.(*tasksform.CreateTaskForm).Description,
 		ClusterIndex:    int64(clusterResp.ClusterIndex),
@@ -131,7 +155,7 @@ func (h *Task) createTaskAction(c *gin.Context) {
 		response.HandleError(response.ResolveError(err), c)
 		return
 	}
-	c.JSON(http.StatusCreated, models.Task{
+	taskResp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -151,71 +175,82 @@ func (h *Task) createTaskAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
 
-	// Создаем канал для синхронизации
-	timerDone1 := make(chan bool)
-	timerDone2 := make(chan bool)
-
-	// Запускаем горутину для ожидания срабатывания таймера
-	go func() {
-		// Создаем таймер
-		timer1 := time.NewTimer(time.Duration(clusterResp.AverageReaction) * time.Second)
-		//timer1 := time.NewTimer(time.Duration(60) * time.Second)
-		<-timer1.C
-		// Таймер истек, отправляем сигнал в канал
-		timerDone1 <- true
-	}()
-
-	// Запускаем горутину для ожидания срабатывания таймера
-	go func() {
-		// Создаем таймер
-		timer2 := time.NewTimer(time.Duration(clusterResp.AverageReaction+delay) * time.Second)
-		//timer2 := time.NewTimer(time.Duration(10) * time.Second)
-		<-timer2.C
-		// Таймер истек, отправляем сигнал в канал
-		timerDone2 <- true
-	}()
-
-	// Ожидаем срабатывания таймера или завершения работы контекста
-	select {
-	case <-timerDone1:
-		// Таймер истек, можно вызвать gRPC-сервер
-		_, err := h.api.TaskService.FireTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.FireTaskRequest{
-			TaskId: task.Id,
-		})
-		if err != nil {
-			log.WithError(err).Errorf("%s: failed to change task status", op)
-			response.HandleError(response.ResolveError(err), c)
-			return
-		}
-		log.Infof("%s: Task status changed successfully", op)
-	case <-ctx.Done():
-		// Контекст отменен, необходимо прекратить ожидание
+	recordAudit(c, log, h.audit, h.secret, "task.create", "task", map[string]int64{"task_id": task.Id}, nil, taskResp)
+
+	c.JSON(http.StatusCreated, taskResp)
+
+	go h.escalateTask(ctx, log, accessToken, task.Id, clusterResp.AverageReaction)
+}
+
+// escalateTask по истечении среднего времени реакции кластера помечает задачу
+// горящей, а после дополнительной задержки назначает на нее исполнителя.
+// Эскалация переживает завершение HTTP запроса, поэтому работает в отвязанном
+// от него контексте, время жизни которого ограничено. Ее спан начинает
+// отдельную трассу со ссылкой на запрос, создавший задачу.
+func (h *Task) escalateTask(ctx context.Context, log *logrus.Entry, accessToken string, taskID int64, averageReaction float64) {
+	const op = "handlers.Task.escalateTask"
+
+	fireAfter := time.Duration(averageReaction) * time.Second
+	appointAfter := time.Duration(averageReaction+delay) * time.Second
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), appointAfter+escalationCallTimeout)
+	defer cancel()
+
+	ctx, span := tracing.Tracer().Start(ctx, "task.escalate",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attribute.Int64("task_id", taskID)),
+	)
+	defer span.End()
+	ctx = metadata.AppendToOutgoingContext(ctx, "access_token", accessToken)
+
+	if !sleepContext(ctx, fireAfter) {
+		log.Warnf("%s: escalation cancelled before firing task %d", op, taskID)
+		return
+	}
+
+	if _, err := h.api.FireTask(ctx, &tasksv1.FireTaskRequest{
+		TaskId: taskID,
+	}); err != nil {
+		log.WithError(err).Errorf("%s: failed to change task status", op)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return
+	}
+	log.Infof("%s: Task status changed successfully", op)
+
+	if !sleepContext(ctx, appointAfter-fireAfter) {
+		log.Warnf("%s: escalation cancelled before appointing task %d", op, taskID)
+		return
+	}
+
+	if _, err := h.api.AppointUserToTask(ctx, &tasksv1.AppointUserToTaskRequest{
+		TaskId: taskID,
+	}); err != nil {
+		log.WithError(err).Errorf("%s: failed to change task status", op)
+		span.SetStatus(otelcodes.Error, err.Error())
 		return
 	}
-	// Ожидаем срабатывания таймера или завершения работы контекста
+	log.Infof("%s: Task status changed successfully", op)
+}
+
+// sleepContext ждет d и возвращает false, если контекст завершился раньше
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
 	select {
-	case <-timerDone2:
-		// Таймер истек, можно вызвать gRPC-сервер
-		_, err := h.api.TaskService.AppointUserToTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.AppointUserToTaskRequest{
-			TaskId: task.Id,
-		})
-		if err != nil {
-			log.WithError(err).Errorf("%s: failed to change task status", op)
-			response.HandleError(response.ResolveError(err), c)
-			return
-		}
-		log.Infof("%s: Task status changed successfully", op)
+	case <-timer.C:
+		return true
 	case <-ctx.Done():
-		// Контекст отменен, необходимо прекратить ожидание
-		return
+		return false
 	}
 }
 
 func (h *Task) changeTaskStatusAction(c *gin.Context) {
 	const op = "handlers.Task.changeTaskStatusAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("change task status")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -224,7 +259,8 @@ func (h *Task) changeTaskStatusAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -233,7 +269,7 @@ func (h *Task) changeTaskStatusAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.ChangeTaskStatus(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ChangeTaskStatusRequest{
+	task, err := h.api.ChangeTaskStatus(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ChangeTaskStatusRequest{
 		TaskId: taskID,
 	})
 	if err != nil {
@@ -242,7 +278,7 @@ func (h *Task) changeTaskStatusAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Task{
+	resp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -266,12 +302,16 @@ func (h *Task) changeTaskStatusAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
+
+	recordAudit(c, log, h.audit, h.secret, "task.change_status", "task", map[string]int64{"task_id": taskID}, nil, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Task) listTasksAction(c *gin.Context) {
 	const op = "handlers.Task.listTasksAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("list tasks")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -280,7 +320,7 @@ func (h *Task) listTasksAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
 	status, err := strconv.Atoi(c.Query("status"))
 	if err != nil {
@@ -289,7 +329,7 @@ func (h *Task) listTasksAction(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.api.TaskService.ListTasks(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ListTasksRequest{
+	tasks, err := h.api.ListTasks(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ListTasksRequest{
 		Status: int64(status),
 	})
 	if err != nil {
@@ -332,7 +372,7 @@ func (h *Task) listTasksAction(c *gin.Context) {
 
 func (h *Task) getTaskAction(c *gin.Context) {
 	const op = "handlers.Task.getTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("get task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -341,7 +381,7 @@ func (h *Task) getTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -350,7 +390,7 @@ func (h *Task) getTaskAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.GetTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.GetTaskRequest{
+	task, err := h.api.GetTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.GetTaskRequest{
 		TaskId: taskID,
 	})
 	if err != nil {
@@ -388,7 +428,7 @@ func (h *Task) getTaskAction(c *gin.Context) {
 
 func (h *Task) AddCaseToTaskAction(c *gin.Context) {
 	const op = "handlers.Task.AddCaseToTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("add case to task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -397,7 +437,8 @@ func (h *Task) AddCaseToTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -413,7 +454,7 @@ func (h *Task) AddCaseToTaskAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.AddCaseToTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.AddCaseToTaskRequest{
+	task, err := h.api.AddCaseToTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.AddCaseToTaskRequest{
 		TaskId: taskID,
 		CaseId: caseID,
 	})
@@ -423,7 +464,7 @@ func (h *Task) AddCaseToTaskAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Task{
+	resp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -447,12 +488,16 @@ func (h *Task) AddCaseToTaskAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
+
+	recordAudit(c, log, h.audit, h.secret, "task.add_case", "task", map[string]int64{"task_id": taskID, "case_id": caseID}, nil, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Task) AddSolutionToTaskAction(c *gin.Context) {
 	const op = "handlers.Task.AddCaseToTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("add case to task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -461,7 +506,8 @@ func (h *Task) AddSolutionToTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -476,7 +522,7 @@ func (h *Task) AddSolutionToTaskAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.AddSolutionToTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.AddSolutionToTaskRequest{
+	task, err := h.api.AddSolutionToTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.AddSolutionToTaskRequest{
 		TaskId:   taskID,
 		Solution: form.(*tasksform.AddSolutionToTaskForm).Solution,
 	})
@@ -486,7 +532,7 @@ func (h *Task) AddSolutionToTaskAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Task{
+	resp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -510,12 +556,16 @@ func (h *Task) AddSolutionToTaskAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
+
+	recordAudit(c, log, h.audit, h.secret, "task.add_solution", "task", map[string]int64{"task_id": taskID}, nil, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Task) RemoveCaseFromTaskAction(c *gin.Context) {
 	const op = "handlers.Task.RemoveCaseFromTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("remove case from task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -524,7 +574,8 @@ func (h *Task) RemoveCaseFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -533,7 +584,7 @@ func (h *Task) RemoveCaseFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.RemoveCaseFromTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.RemoveCaseFromTaskRequest{
+	task, err := h.api.RemoveCaseFromTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.RemoveCaseFromTaskRequest{
 		TaskId: taskID,
 	})
 	if err != nil {
@@ -542,7 +593,7 @@ func (h *Task) RemoveCaseFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Task{
+	resp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -566,12 +617,16 @@ func (h *Task) RemoveCaseFromTaskAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
+
+	recordAudit(c, log, h.audit, h.secret, "task.remove_case", "task", map[string]int64{"task_id": taskID}, nil, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Task) RemoveSolutionFromTaskAction(c *gin.Context) {
 	const op = "handlers.Task.RemoveSolutionFromTaskAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("remove solution from task")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -580,7 +635,8 @@ func (h *Task) RemoveSolutionFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx = grpccli.WithIdempotencyKey(ctx, c.GetHeader(helper.IdempotencyKeyHeader))
 
 	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
 	if err != nil {
@@ -589,7 +645,7 @@ func (h *Task) RemoveSolutionFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	task, err := h.api.TaskService.RemoveSolutionFromTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.RemoveSolutionFromTaskRequest{
+	task, err := h.api.RemoveSolutionFromTask(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.RemoveSolutionFromTaskRequest{
 		TaskId: taskID,
 	})
 	if err != nil {
@@ -598,7 +654,7 @@ func (h *Task) RemoveSolutionFromTaskAction(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Task{
+	resp := models.Task{
 		ID:          task.Id,
 		Title:       task.Title,
 		Description: task.Description,
@@ -622,12 +678,16 @@ func (h *Task) RemoveSolutionFromTaskAction(c *gin.Context) {
 			Name:      task.Cluster.Name,
 			Frequency: task.Cluster.Frequency,
 		},
-	})
+	}
+
+	recordAudit(c, log, h.audit, h.secret, "task.remove_solution", "task", map[string]int64{"task_id": taskID}, nil, resp)
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *Task) listTasksByUserIDAction(c *gin.Context) {
 	const op = "handlers.Task.listTasksByUserIDAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("list tasks by user_id")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -636,7 +696,7 @@ func (h *Task) listTasksByUserIDAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
 	status, err := strconv.Atoi(c.Query("status"))
 	if err != nil {
@@ -652,7 +712,7 @@ func (h *Task) listTasksByUserIDAction(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.api.TaskService.ListTasksByUserID(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ListTasksByUserIDRequest{
+	tasks, err := h.api.ListTasksByUserID(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &tasksv1.ListTasksByUserIDRequest{
 		UserId: userID,
 		Status: int64(status),
 	})
@@ -696,7 +756,7 @@ func (h *Task) listTasksByUserIDAction(c *gin.Context) {
 
 func (h *Task) listUsersAction(c *gin.Context) {
 	const op = "handlers.Task.listUsersAction"
-	log := h.log.WithField("operation", op)
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
 	log.Info("list users")
 
 	accessToken := helper.ExtractTokenFromHeaders(c)
@@ -705,9 +765,9 @@ func (h *Task) listUsersAction(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "app_id", fmt.Sprintf("%d", h.appID))
+	ctx := metadata.AppendToOutgoingContext(c.Request.Context(), "app_id", fmt.Sprintf("%d", h.appID))
 
-	users, err := h.api.TaskService.ListUsers(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &empty.Empty{})
+	users, err := h.api.ListUsers(metadata.AppendToOutgoingContext(ctx, "access_token", accessToken), &empty.Empty{})
 	if err != nil {
 		log.WithError(err).Errorf("%s: failed to list users", op)
 		response.HandleError(response.ResolveError(err), c)
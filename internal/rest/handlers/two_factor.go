@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	grpccli "github.com/markgregr/bestHack_support_REST_server/internal/clients/grpc"
+	twofactorform "github.com/markgregr/bestHack_support_REST_server/internal/rest/forms/twofactor"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+type TwoFactor struct {
+	log        *logrus.Logger
+	store      *twofactor.Store
+	userGuard  *userGuard
+	adminGuard *adminGuard
+}
+
+func NewTwoFactorHandler(api grpccli.AdminAPI, log *logrus.Logger, appID int32, secret string, store *twofactor.Store) *TwoFactor {
+	return &TwoFactor{
+		log:   log,
+		store: store,
+		userGuard: &userGuard{
+			log:    log,
+			secret: secret,
+		},
+		adminGuard: &adminGuard{
+			log:    log,
+			api:    api,
+			appID:  appID,
+			secret: secret,
+		},
+	}
+}
+
+func (h *TwoFactor) EnrichRoutes(router *gin.Engine) {
+	twoFactorRoutes := router.Group("/auth/2fa", h.userGuard.handle)
+	twoFactorRoutes.POST("/enroll", h.enrollAction)
+	twoFactorRoutes.POST("/confirm", h.confirmAction)
+	twoFactorRoutes.POST("/recovery-codes", h.regenerateRecoveryCodesAction)
+	twoFactorRoutes.DELETE("", h.disableAction)
+	adminRoutes := router.Group("/admin", h.adminGuard.handle)
+	adminRoutes.DELETE("/users/:userID/2fa", h.resetAction)
+}
+
+func (h *TwoFactor) enrollAction(c *gin.Context) {
+	const op = "handlers.TwoFactor.enrollAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("enroll two-factor authentication")
+
+	claims := userClaims(c)
+
+	secret, uri, err := h.store.Enroll(claims.UserID, claims.Email)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+			response.HandleError(response.NewTwoFactorEnabledError(), c)
+			return
+		}
+
+		log.WithError(err).Errorf("%s: failed to enroll two-factor authentication", op)
+		response.HandleError(response.NewInternalError(), c)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	})
+}
+
+func (h *TwoFactor) confirmAction(c *gin.Context) {
+	const op = "handlers.TwoFactor.confirmAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("confirm two-factor authentication")
+
+	form, verr := twofactorform.NewCodeForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	codes, err := h.store.Confirm(userClaims(c).UserID, form.(*twofactorform.CodeForm).Code)
+	if err != nil {
+		h.handleStoreError(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodes{
+		RecoveryCodes: codes,
+	})
+}
+
+func (h *TwoFactor) regenerateRecoveryCodesAction(c *gin.Context) {
+	const op = "handlers.TwoFactor.regenerateRecoveryCodesAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("regenerate recovery codes")
+
+	form, verr := twofactorform.NewCodeForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	codes, err := h.store.RegenerateRecoveryCodes(userClaims(c).UserID, form.(*twofactorform.CodeForm).Code)
+	if err != nil {
+		h.handleStoreError(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodes{
+		RecoveryCodes: codes,
+	})
+}
+
+func (h *TwoFactor) disableAction(c *gin.Context) {
+	const op = "handlers.TwoFactor.disableAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("disable two-factor authentication")
+
+	form, verr := twofactorform.NewCodeForm().ParseAndValidate(c)
+	if verr != nil {
+		response.HandleError(verr, c)
+		return
+	}
+
+	userID := userClaims(c).UserID
+
+	// Отключение требует действующего кода, чтобы украденного токена было недостаточно
+	if err := h.store.Verify(userID, form.(*twofactorform.CodeForm).Code); err != nil {
+		h.handleStoreError(c, log, err)
+		return
+	}
+
+	if err := h.store.Reset(userID); err != nil {
+		h.handleStoreError(c, log, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TwoFactor) resetAction(c *gin.Context) {
+	const op = "handlers.TwoFactor.resetAction"
+	log := h.log.WithContext(c.Request.Context()).WithField("operation", op)
+	log.Info("reset two-factor authentication")
+
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		log.WithError(err).Errorf("%s: failed to parse userID", op)
+		response.HandleError(response.ResolveError(err), c)
+		return
+	}
+
+	if err := h.store.Reset(userID); err != nil {
+		h.handleStoreError(c, log, err)
+		return
+	}
+
+	log.WithField("user_id", userID).WithField("admin_id", adminClaims(c).UserID).Warn("two-factor authentication reset by admin")
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TwoFactor) handleStoreError(c *gin.Context, log *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		log.Warn("invalid two-factor code")
+		response.HandleError(response.NewInvalidTwoFactorCodeError(), c)
+	case errors.Is(err, twofactor.ErrNotEnrolled), errors.Is(err, twofactor.ErrNotConfirmed):
+		response.HandleError(response.NewNotFoundError(), c)
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+		response.HandleError(response.NewTwoFactorEnabledError(), c)
+	default:
+		log.WithError(err).Error("two-factor store failure")
+		response.HandleError(response.NewInternalError(), c)
+	}
+}
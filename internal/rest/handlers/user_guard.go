@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const userClaimsKey = "user_claims"
+
+// userGuard пропускает к маршруту пользователей с действующим access токеном.
+// Токен проверяется по подписи, без обращения к SSO.
+type userGuard struct {
+	log    *logrus.Logger
+	secret string
+}
+
+func (g *userGuard) handle(c *gin.Context) {
+	const op = "handlers.userGuard.handle"
+	log := g.log.WithContext(c.Request.Context()).WithField("operation", op)
+
+	accessToken := helper.ExtractTokenFromHeaders(c)
+	if accessToken == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := jwt.Parse(accessToken, g.secret)
+	if err != nil {
+		log.WithError(err).Warn("invalid access token")
+		response.HandleError(response.NewUnauthorizedError(), c)
+		c.Abort()
+		return
+	}
+
+	c.Set(userClaimsKey, claims)
+	c.Next()
+}
+
+func userClaims(c *gin.Context) *jwt.Claims {
+	claims, _ := c.Get(userClaimsKey)
+	if claims == nil {
+		return nil
+	}
+
+	return claims.(*jwt.Claims)
+}
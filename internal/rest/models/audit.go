@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID          string           `json:"id"`
+	Time        time.Time        `json:"time"`
+	ActorID     int64            `json:"actor_id"`
+	ActorEmail  string           `json:"actor_email"`
+	Action      string           `json:"action"`
+	Resource    string           `json:"resource"`
+	ResourceIDs map[string]int64 `json:"resource_ids"`
+	RequestID   string           `json:"request_id"`
+	ClientIP    string           `json:"client_ip"`
+	Before      json.RawMessage  `json:"before"`
+	After       json.RawMessage  `json:"after"`
+}
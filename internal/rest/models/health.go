@@ -0,0 +1,13 @@
+package models
+
+// DependencyStatus не содержит текста ошибок и задержек: проба доступна без авторизации,
+// поэтому подробности проверки пишутся только в лог
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
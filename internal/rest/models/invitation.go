@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Invitation struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	Token          string     `json:"token,omitempty"`
+	Link           string     `json:"link,omitempty"`
+	CreatedBy      int64      `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedUserID int64      `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+}
@@ -1,5 +1,8 @@
 package models
 
 type AuthToken struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
 }
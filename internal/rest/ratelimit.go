@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+)
+
+// RateLimit собирает middleware ограничения частоты запросов из конфигурации.
+// Возвращает nil, если ограничение выключено. Секрет приложения нужен для лимита
+// по пользователю, без него запросы ограничиваются только по IP.
+func RateLimit(cfg *config.RateLimit, secret string) (gin.HandlerFunc, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var defaultLimiter *ratelimit.Limiter
+	if cfg.Default != "" {
+		limit, period, err := ratelimit.ParseLimit(cfg.Default)
+		if err != nil {
+			return nil, fmt.Errorf("default rate limit: %w", err)
+		}
+		defaultLimiter = ratelimit.NewLimiter(limit, period)
+	}
+
+	routes := make(map[string]*ratelimit.Limiter, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		if r == "" {
+			continue
+		}
+
+		rule, err := ratelimit.ParseRule(r)
+		if err != nil {
+			return nil, err
+		}
+		routes[rule.Method+" "+rule.Path] = ratelimit.NewLimiter(rule.Limit, rule.Period)
+	}
+
+	return middleware.RateLimit(routes, defaultLimiter, rateLimitKeys(secret)), nil
+}
+
+// rateLimitKeys ограничивает запрос по IP клиента и, если токен запроса подписан
+// секретом приложения, по пользователю. Поддельный токен с чужим uid не расходует
+// лимит этого пользователя: такой запрос учитывается только по IP.
+func rateLimitKeys(secret string) middleware.RateLimitKeys {
+	return func(c *gin.Context) []string {
+		keys := make([]string, 0, 2)
+
+		if ip := helper.GetRemoteAddr(c.Request); ip != nil {
+			keys = append(keys, "ip:"+*ip)
+		}
+
+		if secret == "" {
+			return keys
+		}
+
+		if token := helper.ExtractTokenFromHeaders(c); token != "" {
+			if claims, err := jwt.Parse(token, secret); err == nil && claims.UserID != 0 {
+				keys = append(keys, "user:"+strconv.FormatInt(claims.UserID, 10))
+			}
+		}
+
+		return keys
+	}
+}
@@ -10,7 +10,7 @@ func (w *Worker) addRouters(router *gin.Engine) {
 	log.Info("adding routers")
 
 	router.NoRoute(func(c *gin.Context) {
-		log.Info("%s %s\n", c.Request.Method, c.Request.URL)
+		log.Infof("%s %s\n", c.Request.Method, c.Request.URL)
 		c.JSON(404, gin.H{"code": "PAGE_NOT_FOUND", "message": "Page not found"})
 	})
 
@@ -0,0 +1,601 @@
+package rest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/totp"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest"
+	"github.com/markgregr/bestHack_support_REST_server/internal/rest/models"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	s.expect(t, g, "liveness", call{method: http.MethodGet, path: "/healthz"}, http.StatusOK)
+	s.expect(t, g, "readiness", call{method: http.MethodGet, path: "/readyz"}, http.StatusOK)
+
+	rec := s.do(t, call{method: http.MethodPost, path: "/auth/login",
+		body: map[string]string{"email": supportEmail, "password": supportPassword}})
+	tokens := decode[models.AuthToken](t, rec)
+	s.expect(t, g, "logout", call{method: http.MethodPost, path: "/auth/logout", token: tokens.AccessToken}, http.StatusNoContent)
+	s.expect(t, g, "readiness with revoked token", call{method: http.MethodGet, path: "/readyz", token: tokens.AccessToken}, http.StatusOK)
+}
+
+func TestAuthRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	s.expect(t, g, "register", call{method: http.MethodPost, path: "/auth/register",
+		body: map[string]string{"email": "new@example.com", "password": "new-password"}}, http.StatusCreated)
+	s.expect(t, g, "register missed fields", call{method: http.MethodPost, path: "/auth/register",
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "register invalid json", call{method: http.MethodPost, path: "/auth/register",
+		body: "{"}, http.StatusBadRequest)
+	s.expect(t, g, "register existing user", call{method: http.MethodPost, path: "/auth/register",
+		body: map[string]string{"email": supportEmail, "password": "other"}}, http.StatusConflict)
+	s.expect(t, g, "register rejected by backend", call{method: http.MethodPost, path: "/auth/register",
+		body: map[string]string{"email": "not-an-email", "password": "password"}}, http.StatusBadRequest)
+
+	rec := s.expect(t, g, "login", call{method: http.MethodPost, path: "/auth/login",
+		body: map[string]string{"email": supportEmail, "password": supportPassword}}, http.StatusOK)
+	tokens := decode[models.AuthToken](t, rec)
+
+	s.expect(t, g, "login invalid credentials", call{method: http.MethodPost, path: "/auth/login",
+		body: map[string]string{"email": supportEmail, "password": "wrong"}}, http.StatusUnauthorized)
+	s.expect(t, g, "login missed fields", call{method: http.MethodPost, path: "/auth/login",
+		body: map[string]string{}}, http.StatusBadRequest)
+
+	s.expect(t, g, "login second factor unknown challenge", call{method: http.MethodPost, path: "/auth/login/2fa",
+		body: map[string]string{"challenge_token": "unknown", "code": "123456"}}, http.StatusUnauthorized)
+	s.expect(t, g, "login second factor missed fields", call{method: http.MethodPost, path: "/auth/login/2fa",
+		body: map[string]string{}}, http.StatusBadRequest)
+
+	rec = s.expect(t, g, "refresh", call{method: http.MethodPost, path: "/auth/refresh",
+		body: map[string]string{"refresh_token": tokens.RefreshToken}}, http.StatusOK)
+	refreshed := decode[models.AuthToken](t, rec)
+	s.expect(t, g, "request with refreshed token", call{method: http.MethodGet, path: "/task/?status=0", token: refreshed.AccessToken}, http.StatusOK)
+	s.expect(t, g, "refresh reused token", call{method: http.MethodPost, path: "/auth/refresh",
+		body: map[string]string{"refresh_token": tokens.RefreshToken}}, http.StatusUnauthorized)
+	s.expect(t, g, "request after refresh token reuse", call{method: http.MethodGet, path: "/task/?status=0", token: refreshed.AccessToken}, http.StatusUnauthorized)
+	s.expect(t, g, "refresh missed token", call{method: http.MethodPost, path: "/auth/refresh",
+		body: map[string]string{}}, http.StatusBadRequest)
+
+	s.expect(t, g, "bot", call{method: http.MethodPost, path: "/auth/bot",
+		body: map[string]string{"email": "bot@example.com", "password": "bot-password", "username": "support_bot"}}, http.StatusOK)
+	s.expect(t, g, "bot invalid credentials", call{method: http.MethodPost, path: "/auth/bot",
+		body: map[string]string{"email": "bot@example.com", "password": "wrong", "username": "support_bot"}}, http.StatusUnauthorized)
+	s.expect(t, g, "bot missed fields", call{method: http.MethodPost, path: "/auth/bot",
+		body: map[string]string{}}, http.StatusBadRequest)
+
+	s.expect(t, g, "logout without token", call{method: http.MethodPost, path: "/auth/logout"}, http.StatusUnauthorized)
+	rec = s.do(t, call{method: http.MethodPost, path: "/auth/login",
+		body: map[string]string{"email": supportEmail, "password": supportPassword}})
+	tokens = decode[models.AuthToken](t, rec)
+	s.expect(t, g, "logout", call{method: http.MethodPost, path: "/auth/logout", token: tokens.AccessToken}, http.StatusNoContent)
+	s.expect(t, g, "request after logout", call{method: http.MethodGet, path: "/task/?status=0", token: tokens.AccessToken}, http.StatusUnauthorized)
+	s.expect(t, g, "logout with revoked token", call{method: http.MethodPost, path: "/auth/logout", token: tokens.AccessToken}, http.StatusUnauthorized)
+}
+
+func TestTaskRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+	task := fmt.Sprintf("/task/%d", s.taskID)
+
+	rec := s.expect(t, g, "create", call{method: http.MethodPost, path: "/task/", token: s.supportToken,
+		body: map[string]string{"title": "Сброс пароля", "description": "Не приходит письмо для сброса пароля"}}, http.StatusCreated)
+	created := decode[models.Task](t, rec)
+	s.expect(t, g, "create missed fields", call{method: http.MethodPost, path: "/task/", token: s.supportToken,
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "create without token", call{method: http.MethodPost, path: "/task/",
+		body: map[string]string{"title": "title", "description": "description"}}, http.StatusUnauthorized)
+
+	s.expect(t, g, "list open", call{method: http.MethodGet, path: "/task/?status=0", token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "list invalid status", call{method: http.MethodGet, path: "/task/?status=open", token: s.supportToken}, http.StatusInternalServerError)
+	s.expect(t, g, "list with invalid token", call{method: http.MethodGet, path: "/task/?status=0", token: "invalid"}, http.StatusUnauthorized)
+
+	s.expect(t, g, "get", call{method: http.MethodGet, path: task, token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "get unknown", call{method: http.MethodGet, path: "/task/999", token: s.supportToken}, http.StatusNotFound)
+	s.expect(t, g, "get invalid id", call{method: http.MethodGet, path: "/task/abc", token: s.supportToken}, http.StatusInternalServerError)
+
+	s.expect(t, g, "add case", call{method: http.MethodPut, path: fmt.Sprintf("%s/case/%d", task, s.caseID), token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "add unknown case", call{method: http.MethodPut, path: task + "/case/999", token: s.supportToken}, http.StatusNotFound)
+	s.expect(t, g, "remove case", call{method: http.MethodDelete, path: task + "/case", token: s.supportToken}, http.StatusOK)
+
+	s.expect(t, g, "add solution", call{method: http.MethodPut, path: task, token: s.supportToken,
+		body: map[string]string{"solution": "Отправили письмо повторно"}}, http.StatusOK)
+	s.expect(t, g, "add solution missed fields", call{method: http.MethodPut, path: task, token: s.supportToken,
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "remove solution", call{method: http.MethodDelete, path: task + "/solution", token: s.supportToken}, http.StatusOK)
+
+	s.expect(t, g, "take in progress", call{method: http.MethodPost, path: task + "/status", token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "close", call{method: http.MethodPost, path: task + "/status", token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "change closed", call{method: http.MethodPost, path: task + "/status", token: s.supportToken}, http.StatusBadRequest)
+	s.expect(t, g, "add solution to closed", call{method: http.MethodPut, path: task, token: s.supportToken,
+		body: map[string]string{"solution": "solution"}}, http.StatusBadRequest)
+
+	s.expect(t, g, "take created in progress", call{method: http.MethodPost, path: fmt.Sprintf("/task/%d/status", created.ID), token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "list by user", call{method: http.MethodGet, path: fmt.Sprintf("/user/%d/task?status=1", s.supportID), token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "list by user invalid id", call{method: http.MethodGet, path: "/user/abc/task?status=1", token: s.supportToken}, http.StatusInternalServerError)
+	s.expect(t, g, "list users", call{method: http.MethodGet, path: "/user/", token: s.adminToken}, http.StatusOK)
+	s.expect(t, g, "list users without token", call{method: http.MethodGet, path: "/user/"}, http.StatusUnauthorized)
+}
+
+func TestClusterRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+	cluster := fmt.Sprintf("/cluster/%d", seedClusterID)
+	caseItem := fmt.Sprintf("/cases/%d", s.caseID)
+
+	s.expect(t, g, "list", call{method: http.MethodGet, path: "/cluster/", token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "list without token", call{method: http.MethodGet, path: "/cluster/"}, http.StatusUnauthorized)
+	s.expect(t, g, "list cases", call{method: http.MethodGet, path: cluster, token: s.supportToken}, http.StatusOK)
+	s.expect(t, g, "list cases of unknown cluster", call{method: http.MethodGet, path: "/cluster/999", token: s.supportToken}, http.StatusNotFound)
+
+	s.expect(t, g, "create case", call{method: http.MethodPost, path: cluster, token: s.supportToken,
+		body: map[string]string{"title": "Аккаунт заблокирован", "solution": "Подождать 15 минут"}}, http.StatusCreated)
+	s.expect(t, g, "create case missed fields", call{method: http.MethodPost, path: cluster, token: s.supportToken,
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "create case in unknown cluster", call{method: http.MethodPost, path: "/cluster/999", token: s.supportToken,
+		body: map[string]string{"title": "title", "solution": "solution"}}, http.StatusNotFound)
+	s.expect(t, g, "list cases after create", call{method: http.MethodGet, path: cluster, token: s.supportToken}, http.StatusOK)
+
+	s.expect(t, g, "rename", call{method: http.MethodPut, path: cluster, token: s.supportToken,
+		body: map[string]string{"name": "Вход в аккаунт"}}, http.StatusOK)
+	s.expect(t, g, "rename missed fields", call{method: http.MethodPut, path: cluster, token: s.supportToken,
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "list after rename", call{method: http.MethodGet, path: "/cluster/", token: s.supportToken}, http.StatusOK)
+
+	s.expect(t, g, "update case", call{method: http.MethodPut, path: caseItem, token: s.supportToken,
+		body: map[string]string{"title": "Сброс пароля по почте", "solution": "Отправить письмо повторно"}}, http.StatusOK)
+	s.expect(t, g, "update unknown case", call{method: http.MethodPut, path: "/cases/999", token: s.supportToken,
+		body: map[string]string{"title": "title", "solution": "solution"}}, http.StatusNotFound)
+	s.expect(t, g, "delete case", call{method: http.MethodDelete, path: caseItem, token: s.supportToken}, http.StatusNoContent)
+	s.expect(t, g, "delete unknown case", call{method: http.MethodDelete, path: caseItem, token: s.supportToken}, http.StatusNotFound)
+}
+
+func TestSessionRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	login := call{method: http.MethodPost, path: "/auth/login", body: map[string]string{"email": supportEmail, "password": supportPassword}}
+	current := decode[models.AuthToken](t, s.do(t, login))
+	s.do(t, login)
+	s.do(t, login)
+
+	rec := s.expect(t, g, "list", call{method: http.MethodGet, path: "/auth/sessions", token: current.AccessToken}, http.StatusOK)
+	var other string
+	for _, session := range decode[[]models.Session](t, rec) {
+		if !session.Current {
+			other = session.ID
+		}
+	}
+
+	s.expect(t, g, "list without token", call{method: http.MethodGet, path: "/auth/sessions"}, http.StatusUnauthorized)
+	s.expect(t, g, "revoke", call{method: http.MethodDelete, path: "/auth/sessions/" + other, token: current.AccessToken}, http.StatusNoContent)
+	s.expect(t, g, "revoke unknown", call{method: http.MethodDelete, path: "/auth/sessions/" + other, token: current.AccessToken}, http.StatusNotFound)
+	s.expect(t, g, "revoke others", call{method: http.MethodDelete, path: "/auth/sessions", token: current.AccessToken}, http.StatusNoContent)
+	s.expect(t, g, "list after revoke", call{method: http.MethodGet, path: "/auth/sessions", token: current.AccessToken}, http.StatusOK)
+}
+
+func TestTwoFactorRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+	login := call{method: http.MethodPost, path: "/auth/login", body: map[string]string{"email": supportEmail, "password": supportPassword}}
+
+	recoveryCodes := s.enrollTwoFactor(t, g, "")
+
+	rec := s.expect(t, g, "login requires second factor", login, http.StatusAccepted)
+	challenge := decode[models.TwoFactorChallenge](t, rec)
+	s.expect(t, g, "login second factor invalid code", call{method: http.MethodPost, path: "/auth/login/2fa",
+		body: map[string]string{"challenge_token": challenge.ChallengeToken, "code": "000000"}}, http.StatusUnauthorized)
+	s.expect(t, g, "login second factor", call{method: http.MethodPost, path: "/auth/login/2fa",
+		body: map[string]string{"challenge_token": challenge.ChallengeToken, "code": recoveryCodes[0]}}, http.StatusOK)
+
+	s.expect(t, g, "enroll when enabled", call{method: http.MethodPost, path: "/auth/2fa/enroll", token: s.supportToken}, http.StatusConflict)
+	rec = s.expect(t, g, "regenerate recovery codes", call{method: http.MethodPost, path: "/auth/2fa/recovery-codes", token: s.supportToken,
+		body: map[string]string{"code": recoveryCodes[1]}}, http.StatusOK)
+	recoveryCodes = decode[models.RecoveryCodes](t, rec).RecoveryCodes
+	s.expect(t, g, "regenerate recovery codes invalid code", call{method: http.MethodPost, path: "/auth/2fa/recovery-codes", token: s.supportToken,
+		body: map[string]string{"code": "000000"}}, http.StatusUnauthorized)
+	s.expect(t, g, "disable missed code", call{method: http.MethodDelete, path: "/auth/2fa", token: s.supportToken,
+		body: map[string]string{}}, http.StatusBadRequest)
+	s.expect(t, g, "disable", call{method: http.MethodDelete, path: "/auth/2fa", token: s.supportToken,
+		body: map[string]string{"code": recoveryCodes[0]}}, http.StatusNoContent)
+	s.expect(t, g, "login after disable", login, http.StatusOK)
+
+	s.enrollTwoFactor(t, g, "again ")
+	reset := fmt.Sprintf("/admin/users/%d/2fa", s.supportID)
+	s.expect(t, g, "reset by operator", call{method: http.MethodDelete, path: reset, token: s.supportToken}, http.StatusForbidden)
+	s.expect(t, g, "reset", call{method: http.MethodDelete, path: reset, token: s.adminToken}, http.StatusNoContent)
+	s.expect(t, g, "reset when disabled", call{method: http.MethodDelete, path: reset, token: s.adminToken}, http.StatusNotFound)
+	s.expect(t, g, "reset invalid user id", call{method: http.MethodDelete, path: "/admin/users/abc/2fa", token: s.adminToken}, http.StatusInternalServerError)
+	s.expect(t, g, "enroll without token", call{method: http.MethodPost, path: "/auth/2fa/enroll"}, http.StatusUnauthorized)
+}
+
+// enrollTwoFactor включает 2FA сотруднику поддержки и возвращает коды восстановления
+func (s *testServer) enrollTwoFactor(t *testing.T, g *golden, prefix string) []string {
+	t.Helper()
+
+	rec := s.expect(t, g, prefix+"enroll", call{method: http.MethodPost, path: "/auth/2fa/enroll", token: s.supportToken}, http.StatusOK)
+	enrollment := decode[models.TwoFactorEnrollment](t, rec)
+
+	s.expect(t, g, prefix+"confirm invalid code", call{method: http.MethodPost, path: "/auth/2fa/confirm", token: s.supportToken,
+		body: map[string]string{"code": "000000"}}, http.StatusUnauthorized)
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("generate totp code: %v", err)
+	}
+	rec = s.expect(t, g, prefix+"confirm", call{method: http.MethodPost, path: "/auth/2fa/confirm", token: s.supportToken,
+		body: map[string]string{"code": code}}, http.StatusOK)
+
+	return decode[models.RecoveryCodes](t, rec).RecoveryCodes
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+	create := map[string]any{"name": "ci", "user_id": s.supportID, "email": supportEmail, "scopes": []string{"tasks:read"}}
+
+	rec := s.expect(t, g, "create", call{method: http.MethodPost, path: "/admin/api-keys", token: s.adminToken, body: create}, http.StatusCreated)
+	key := decode[models.APIKey](t, rec)
+	s.expect(t, g, "create missed fields", call{method: http.MethodPost, path: "/admin/api-keys", token: s.adminToken,
+		body: map[string]any{}}, http.StatusBadRequest)
+	s.expect(t, g, "create unknown scope", call{method: http.MethodPost, path: "/admin/api-keys", token: s.adminToken,
+		body: map[string]any{"name": "ci", "user_id": s.supportID, "scopes": []string{"tasks:delete"}}}, http.StatusBadRequest)
+	s.expect(t, g, "create by operator", call{method: http.MethodPost, path: "/admin/api-keys", token: s.supportToken, body: create}, http.StatusForbidden)
+	s.expect(t, g, "create without token", call{method: http.MethodPost, path: "/admin/api-keys", body: create}, http.StatusUnauthorized)
+
+	s.expect(t, g, "list", call{method: http.MethodGet, path: "/admin/api-keys", token: s.adminToken}, http.StatusOK)
+	s.expect(t, g, "request with key", call{method: http.MethodGet, path: "/task/?status=0",
+		headers: map[string]string{"X-API-Key": key.Key}}, http.StatusOK)
+	s.expect(t, g, "request outside key scope", call{method: http.MethodGet, path: "/cluster/",
+		headers: map[string]string{"X-API-Key": key.Key}}, http.StatusForbidden)
+
+	s.expect(t, g, "revoke", call{method: http.MethodDelete, path: "/admin/api-keys/" + key.ID, token: s.adminToken}, http.StatusNoContent)
+	s.expect(t, g, "revoke unknown", call{method: http.MethodDelete, path: "/admin/api-keys/unknown", token: s.adminToken}, http.StatusNotFound)
+	s.expect(t, g, "request with revoked key", call{method: http.MethodGet, path: "/task/?status=0",
+		headers: map[string]string{"X-API-Key": key.Key}}, http.StatusUnauthorized)
+}
+
+func TestInvitationRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	rec := s.expect(t, g, "create", call{method: http.MethodPost, path: "/admin/invitations", token: s.adminToken,
+		body: map[string]string{"email": "invited@example.com"}}, http.StatusCreated)
+	invitation := decode[models.Invitation](t, rec)
+	s.expect(t, g, "create invalid fields", call{method: http.MethodPost, path: "/admin/invitations", token: s.adminToken,
+		body: map[string]string{"email": "invited", "expires_at": "yesterday"}}, http.StatusBadRequest)
+	s.expect(t, g, "create by operator", call{method: http.MethodPost, path: "/admin/invitations", token: s.supportToken,
+		body: map[string]string{"email": "invited@example.com"}}, http.StatusForbidden)
+
+	s.expect(t, g, "list", call{method: http.MethodGet, path: "/admin/invitations", token: s.adminToken}, http.StatusOK)
+	s.expect(t, g, "list by status", call{method: http.MethodGet, path: "/admin/invitations?status=accepted", token: s.adminToken}, http.StatusOK)
+
+	s.expect(t, g, "revoke", call{method: http.MethodDelete, path: "/admin/invitations/" + invitation.ID, token: s.adminToken}, http.StatusNoContent)
+	s.expect(t, g, "revoke twice", call{method: http.MethodDelete, path: "/admin/invitations/" + invitation.ID, token: s.adminToken}, http.StatusNoContent)
+	s.expect(t, g, "revoke unknown", call{method: http.MethodDelete, path: "/admin/invitations/unknown", token: s.adminToken}, http.StatusNotFound)
+}
+
+func TestInviteOnly(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Invitations.Required = true
+	})
+
+	for name, c := range map[string]struct {
+		call call
+		want int
+	}{
+		"register without invitation": {call{method: http.MethodPost, path: "/auth/register",
+			body: map[string]string{"email": "new@example.com", "password": "new-password"}}, http.StatusForbidden},
+		"bot registers unknown user": {call{method: http.MethodPost, path: "/auth/bot",
+			body: map[string]string{"email": "bot@example.com", "password": "bot-password", "username": "support_bot"}}, http.StatusUnauthorized},
+		"bot signs in existing user": {call{method: http.MethodPost, path: "/auth/bot",
+			body: map[string]string{"email": supportEmail, "password": supportPassword, "username": "support_bot"}}, http.StatusOK},
+	} {
+		if rec := s.do(t, c.call); rec.Code != c.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, c.want)
+		}
+	}
+
+	rec := s.do(t, call{method: http.MethodGet, path: "/auth/oidc/login"})
+	callback := s.authorize(t, rec)
+	if rec := s.do(t, call{method: http.MethodGet, path: callback.RequestURI(), cookies: rec.Result().Cookies()}); rec.Code != http.StatusForbidden {
+		t.Errorf("oidc provisioning without invitation: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = s.do(t, call{method: http.MethodPost, path: "/admin/invitations", token: s.adminToken,
+		body: map[string]string{"email": "user@example.com"}})
+	invitation := decode[models.Invitation](t, rec)
+
+	rec = s.do(t, call{method: http.MethodGet, path: "/auth/oidc/login?invite=" + url.QueryEscape(invitation.Token)})
+	callback = s.authorize(t, rec)
+	if rec := s.do(t, call{method: http.MethodGet, path: callback.RequestURI(), cookies: rec.Result().Cookies()}); rec.Code != http.StatusOK {
+		t.Errorf("oidc provisioning with invitation: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = s.do(t, call{method: http.MethodGet, path: "/admin/invitations?status=accepted", token: s.adminToken})
+	if accepted := decode[[]models.Invitation](t, rec); len(accepted) != 1 || accepted[0].ID != invitation.ID {
+		t.Errorf("accepted invitations = %+v, want %s", accepted, invitation.ID)
+	}
+}
+
+func TestAuditRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	s.do(t, call{method: http.MethodPut, path: fmt.Sprintf("/cluster/%d", seedClusterID), token: s.supportToken,
+		body: map[string]string{"name": "Вход в аккаунт"}})
+	// Состояние до изменения берется только из кэша кластеров, поэтому удалению предшествует чтение
+	s.do(t, call{method: http.MethodGet, path: "/cluster/", token: s.supportToken})
+	s.do(t, call{method: http.MethodGet, path: fmt.Sprintf("/cluster/%d", seedClusterID), token: s.supportToken})
+	s.do(t, call{method: http.MethodDelete, path: fmt.Sprintf("/cases/%d", s.caseID), token: s.supportToken})
+
+	s.expect(t, g, "list", call{method: http.MethodGet, path: "/audit", token: s.adminToken}, http.StatusOK)
+	s.expect(t, g, "list by resource", call{method: http.MethodGet, path: "/audit?resource=case&limit=10", token: s.adminToken}, http.StatusOK)
+	s.expect(t, g, "list invalid filter", call{method: http.MethodGet, path: "/audit?limit=0&actor=abc", token: s.adminToken}, http.StatusBadRequest)
+	s.expect(t, g, "list by operator", call{method: http.MethodGet, path: "/audit", token: s.supportToken}, http.StatusForbidden)
+	s.expect(t, g, "list without token", call{method: http.MethodGet, path: "/audit"}, http.StatusUnauthorized)
+}
+
+func TestOIDCRoutes(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+
+	rec := s.expect(t, g, "login", call{method: http.MethodGet, path: "/auth/oidc/login"}, http.StatusFound)
+	callback := s.authorize(t, rec)
+
+	s.expect(t, g, "callback with foreign state", call{method: http.MethodGet, path: callback.RequestURI()}, http.StatusUnauthorized)
+
+	rec = s.do(t, call{method: http.MethodGet, path: "/auth/oidc/login"})
+	callback = s.authorize(t, rec)
+	s.expect(t, g, "callback", call{method: http.MethodGet, path: callback.RequestURI(), cookies: rec.Result().Cookies()}, http.StatusOK)
+	s.expect(t, g, "callback replayed", call{method: http.MethodGet, path: callback.RequestURI(), cookies: rec.Result().Cookies()}, http.StatusUnauthorized)
+}
+
+// authorize проходит авторизацию у IdP по ссылке из ответа шлюза и возвращает адрес callback с кодом
+func (s *testServer) authorize(t *testing.T, rec *httptest.ResponseRecorder) *url.URL {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize at idp: %v", err)
+	}
+	defer resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || callback.Query().Get("code") == "" {
+		t.Fatalf("idp did not redirect with code: %q", resp.Header.Get("Location"))
+	}
+
+	return callback
+}
+
+func TestBackendErrorMapping(t *testing.T) {
+	s := newTestServer(t)
+	g := newGolden(t)
+	const method = "/tasks.TaskService/GetTask"
+
+	withDetails := func(st *status.Status, details ...*errdetails.RetryInfo) error {
+		for _, d := range details {
+			st, _ = st.WithDetails(d)
+		}
+		return st.Err()
+	}
+	badRequest, _ := status.New(codes.InvalidArgument, "invalid task").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "task_id", Description: "must be positive"}},
+	})
+	precondition, _ := status.New(codes.FailedPrecondition, "task is closed").WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{Type: "STATUS", Subject: "status", Description: "task is closed"}},
+	})
+	retryInfo := &errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid argument", status.Error(codes.InvalidArgument, "invalid task"), http.StatusBadRequest},
+		{"invalid argument with details", badRequest.Err(), http.StatusBadRequest},
+		{"failed precondition with details", precondition.Err(), http.StatusBadRequest},
+		{"not found", status.Error(codes.NotFound, "task not found"), http.StatusNotFound},
+		{"already exists", status.Error(codes.AlreadyExists, "task exists"), http.StatusConflict},
+		{"permission denied", status.Error(codes.PermissionDenied, "forbidden"), http.StatusForbidden},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "token expired"), http.StatusUnauthorized},
+		{"resource exhausted", withDetails(status.New(codes.ResourceExhausted, "slow down"), retryInfo), http.StatusTooManyRequests},
+		{"unavailable", withDetails(status.New(codes.Unavailable, "maintenance"), retryInfo), http.StatusServiceUnavailable},
+		{"deadline exceeded", status.Error(codes.DeadlineExceeded, "timeout"), http.StatusGatewayTimeout},
+		{"unimplemented", status.Error(codes.Unimplemented, "unimplemented"), http.StatusNotImplemented},
+		{"internal", status.Error(codes.Internal, "boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		s.faults.set(method, tc.err)
+		s.expect(t, g, tc.name, call{method: http.MethodGet, path: fmt.Sprintf("/task/%d", s.taskID), token: s.supportToken}, tc.status)
+	}
+	s.faults.set(method, nil)
+
+	s.expect(t, g, "unknown route", call{method: http.MethodGet, path: "/unknown"}, http.StatusNotFound)
+}
+
+func TestBackendMetadata(t *testing.T) {
+	s := newTestServer(t)
+	const (
+		method      = "/tasks.TaskService/GetTask"
+		traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+		traceParent = "00-" + traceID + "-00f067aa0ba902b7-01"
+	)
+
+	rec := s.do(t, call{method: http.MethodGet, path: fmt.Sprintf("/task/%d", s.taskID), token: s.supportToken, headers: map[string]string{
+		"X-Request-ID": "req-1",
+		"traceparent":  traceParent,
+		"tracestate":   "vendor=value",
+	}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	md := s.faults.received(method)
+	want := map[string]string{
+		reqmeta.RequestIDKey:  "req-1",
+		reqmeta.ClientIPKey:   "192.0.2.1",
+		reqmeta.UserAgentKey:  userAgent,
+		reqmeta.APIVersionKey: rest.APIVersion,
+		reqmeta.TraceStateKey: "vendor=value",
+		"app_id":              fmt.Sprint(testAppID),
+	}
+	for key, value := range want {
+		if got := md.Get(key); len(got) != 1 || got[0] != value {
+			t.Errorf("metadata %s = %v, want %q", key, got, value)
+		}
+	}
+
+	got := md.Get(reqmeta.TraceParentKey)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "00-"+traceID+"-") || got[0] == traceParent {
+		t.Errorf("traceparent = %v, want child of %q", got, traceParent)
+	}
+
+	s.do(t, call{method: http.MethodGet, path: fmt.Sprintf("/task/%d", s.taskID), token: s.supportToken})
+	if got := s.faults.received(method).Get(reqmeta.TraceParentKey); len(got) != 1 || strings.Contains(got[0], traceID) {
+		t.Errorf("traceparent without incoming trace = %v, want new trace", got)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	s := newTestServer(t)
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	rec := s.do(t, call{method: http.MethodPost, path: "/task/", token: s.supportToken,
+		body:    map[string]string{"title": "Сброс пароля", "description": "Не приходит письмо"},
+		headers: map[string]string{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	for _, name := range []string{"/task/", "tasks.TaskService/CreateTask", "HTTP POST"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("span %q was not recorded, got %v", name, spanNames(recorder.Ended()))
+			continue
+		}
+		if got := span.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %q trace id = %s, want %s", name, got, traceID)
+		}
+	}
+
+	got := s.faults.received("/tasks.TaskService/CreateTask").Get(reqmeta.TraceParentKey)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "00-"+traceID+"-") {
+		t.Errorf("backend traceparent = %v, want trace %s", got, traceID)
+	}
+
+	// Эскалация стартует в фоне после ответа
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, span := range recorder.Started() {
+			if span.Name() != "task.escalate" {
+				continue
+			}
+			if span.SpanContext().TraceID().String() == traceID {
+				t.Errorf("escalation span must start a new trace")
+			}
+			if links := span.Links(); len(links) != 1 || links[0].SpanContext.TraceID().String() != traceID {
+				t.Errorf("escalation span links = %v, want link to trace %s", links, traceID)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("escalation span was not started")
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
+
+func TestRequestID(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.do(t, call{method: http.MethodGet, path: fmt.Sprintf("/task/%d", s.taskID), token: s.supportToken,
+		headers: map[string]string{"X-Request-ID": "req-42"}})
+	if got := rec.Header().Get("X-Request-ID"); got != "req-42" {
+		t.Errorf("response header = %q, want client request id", got)
+	}
+	if got := s.faults.received("/tasks.TaskService/GetTask").Get(reqmeta.RequestIDKey); len(got) != 1 || got[0] != "req-42" {
+		t.Errorf("backend request_id = %v, want client request id", got)
+	}
+
+	var access, handler, grpcCall bool
+	for _, entry := range s.logs.AllEntries() {
+		if entry.Data[reqmeta.RequestIDKey] != "req-42" {
+			continue
+		}
+		switch {
+		case entry.Data["statusCode"] != nil:
+			access = true
+		case entry.Data["operation"] == "handlers.Task.getTaskAction":
+			handler = true
+		case entry.Data["grpc.method"] == "GetTask":
+			grpcCall = true
+		}
+	}
+	if !access || !handler || !grpcCall {
+		t.Errorf("request id in logs: access=%v handler=%v grpc=%v, want all", access, handler, grpcCall)
+	}
+
+	rec = s.do(t, call{method: http.MethodGet, path: "/task/999", token: s.supportToken,
+		headers: map[string]string{"X-Request-ID": "req-43"}})
+	if body := decode[map[string]any](t, rec); body["request_id"] != "req-43" {
+		t.Errorf("error body request_id = %v, want client request id", body["request_id"])
+	}
+
+	for name, header := range map[string]string{
+		"missing":  "",
+		"too long": strings.Repeat("a", 129),
+		"spaces":   "req 42",
+	} {
+		rec := s.do(t, call{method: http.MethodGet, path: "/healthz", headers: map[string]string{"X-Request-ID": header}})
+		if got := rec.Header().Get("X-Request-ID"); len(got) != 32 || got == header {
+			t.Errorf("%s: response header = %q, want generated request id", name, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/tlsreload"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig собирает TLS конфигурацию сервера. Сертификат берется из reloader,
+// поэтому обновление файлов на диске применяется без перезапуска.
+// Набор шифров настраивается только для TLS 1.2, в TLS 1.3 он фиксирован.
+func NewTLSConfig(cfg *config.TLS, reloader *tlsreload.Reloader) (*tls.Config, error) {
+	if !reloader.HasCertificate() {
+		return nil, fmt.Errorf("tls: certificate and key files are required")
+	}
+
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("tls: unsupported min version %q, expected 1.2 or 1.3", cfg.MinVersion)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, s := range tls.CipherSuites() {
+			suites[s.Name] = s.ID
+		}
+
+		for _, name := range cfg.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("tls: unknown or insecure cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewRedirectServer создает HTTP сервер, перенаправляющий все запросы на HTTPS порт
+func NewRedirectServer(cfg *config.HTTPServer) *http.Server {
+	return &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.TLS.RedirectPort)),
+		ReadHeaderTimeout: cfg.Timeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+
+			target := "https://" + host
+			if cfg.Port != 443 {
+				target = "https://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+			}
+
+			http.Redirect(w, r, target+r.URL.RequestURI(), http.StatusMovedPermanently)
+		}),
+	}
+}
@@ -2,36 +2,55 @@ package rest
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/markgregr/bestHack_support_REST_server/internal/config"
 	"github.com/markgregr/bestHack_support_REST_server/internal/rest/handlers"
+	"github.com/markgregr/bestHack_support_REST_server/internal/tracing"
 	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
+	"net/http"
 	"time"
 
-	ginlogrus "github.com/Toorop/gin-logrus"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 const (
 	checkingInterval = time.Minute
+
+	// APIVersion - версия REST API, которую шлюз сообщает бэкенду
+	APIVersion = "1"
 )
 
 type Worker struct {
 	cfgRest     *config.HTTPServer
 	logger      *log.Logger
+	probes      []handlers.APIHandler
 	apiHandlers []handlers.APIHandler
+	tlsConfig   *tls.Config
+	middlewares []gin.HandlerFunc
 }
 
+// NewWorker создает REST воркер. Если tlsConfig не nil, сервер слушает HTTPS.
+// middlewares подключаются после CORS и CSRF в переданном порядке.
+// Маршруты probes регистрируются раньше CORS, CSRF и middlewares и не проходят через них
 func NewWorker(
 	cfgRest *config.HTTPServer,
 	logger *log.Logger,
+	probes []handlers.APIHandler,
 	apiHandlers []handlers.APIHandler,
+	tlsConfig *tls.Config,
+	middlewares ...gin.HandlerFunc,
 ) *Worker {
 	w := &Worker{
 		cfgRest:     cfgRest,
 		logger:      logger,
+		probes:      probes,
 		apiHandlers: apiHandlers,
+		tlsConfig:   tlsConfig,
+		middlewares: middlewares,
 	}
 
 	return w
@@ -43,7 +62,7 @@ func (w *Worker) Start(ctx context.Context) error {
 
 	for {
 		log.Info("started rest worker")
-		if err := w.run(); err != nil {
+		if err := w.run(ctx); err != nil {
 			return fmt.Errorf("%s: %w", op, err)
 		}
 		select {
@@ -54,35 +73,101 @@ func (w *Worker) Start(ctx context.Context) error {
 	}
 }
 
-func (w *Worker) run() error {
-	const op = "rest.Worker.run"
+// Router собирает роутер со всеми middleware и маршрутами обработчиков
+func (w *Worker) Router() (*gin.Engine, error) {
+	const op = "rest.Worker.Router"
 	log := w.logger.WithField("method", op)
 
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
-	router.Use(ginlogrus.Logger(w.logger), gin.Recovery(), middleware.Prometheus(), middleware.Panic(w.logger))
+	if err := router.SetTrustedProxies(w.cfgRest.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	router.Use(middleware.RequestID(), middleware.Logger(w.logger), gin.Recovery(), middleware.Prometheus(), middleware.Panic(w.logger))
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(traced)))
+	router.Use(middleware.Metadata(APIVersion))
+
+	// Gin применяет к маршруту только middleware, подключенные до его регистрации
+	for _, h := range w.probes {
+		log.WithField("handler", h).Info("enriching probe routes")
+		h.EnrichRoutes(router)
+	}
 
 	if w.cfgRest.AllowOrigin != "" {
 		log.WithField("allow_origin", w.cfgRest.AllowOrigin).Info("setting up CORS")
 		router.Use(CORS(w.cfgRest.AllowOrigin))
 	}
 
+	router.Use(middleware.CSRF())
+
+	router.Use(w.middlewares...)
+
 	for _, h := range w.apiHandlers {
 		log.WithField("handler", h).Info("enriching routes")
 		h.EnrichRoutes(router)
 	}
 
 	w.addRouters(router)
-	log.WithField("host", w.cfgRest.Host).WithField("port", w.cfgRest.Port).Info("running rest worker")
-	return router.Run(fmt.Sprintf("%s:%d", w.cfgRest.Host, w.cfgRest.Port))
+
+	return router, nil
+}
+
+// traced отбрасывает пробы liveness и readiness, чтобы они не занимали трассы
+func traced(r *http.Request) bool {
+	return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
+func (w *Worker) run(ctx context.Context) error {
+	const op = "rest.Worker.run"
+	log := w.logger.WithField("method", op)
+
+	router, err := w.Router()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	server := &http.Server{
+		Addr:        fmt.Sprintf("%s:%d", w.cfgRest.Host, w.cfgRest.Port),
+		Handler:     router,
+		IdleTimeout: w.cfgRest.IdleTimeout,
+		TLSConfig:   w.tlsConfig,
+	}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := server.Shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("failed to shutdown rest server")
+			}
+		case <-stopped:
+		}
+	}()
+
+	log.WithField("host", w.cfgRest.Host).WithField("port", w.cfgRest.Port).WithField("tls", w.tlsConfig != nil).Info("running rest worker")
+
+	if w.tlsConfig != nil {
+		// Сертификат отдает TLSConfig.GetCertificate, поэтому пути к файлам не передаются
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+
+	return err
 }
 
 func CORS(allowOrigin string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, X-API-Key, Idempotency-Key, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Offset, X-Limit, X-Next-Page")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "HEAD, GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Allow", "HEAD, GET, POST, PUT, PATCH, DELETE, OPTIONS")
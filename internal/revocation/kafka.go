@@ -0,0 +1,202 @@
+package revocation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+const retryDelay = 5 * time.Second
+
+type event struct {
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Origin    string    `json:"origin"`
+}
+
+// KafkaSync синхронизирует список отзыва между экземплярами шлюза через топик Kafka.
+// Список хранится в памяти, поэтому каждый экземпляр при старте читает все партиции
+// топика с начала без consumer group: после перезапуска он получает все отзывы,
+// которые еще хранятся в топике, и не оставляет на брокере брошенных групп.
+// Retention топика стоит выставить не меньше времени жизни access токенов.
+// Партиции, добавленные в топик после старта, читаются после перезапуска.
+type KafkaSync struct {
+	log     *logrus.Entry
+	list    *List
+	origin  string
+	brokers []string
+	topic   string
+	writer  *kafka.Writer
+}
+
+func NewKafkaSync(log *logrus.Logger, list *List, brokers []string, topic string) (*KafkaSync, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	s := &KafkaSync{
+		log:     log.WithField("component", "revocation.KafkaSync"),
+		list:    list,
+		origin:  hex.EncodeToString(b),
+		brokers: brokers,
+		topic:   topic,
+	}
+
+	s.writer = &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireOne,
+		BatchTimeout: 50 * time.Millisecond,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				s.log.WithError(err).WithField("count", len(messages)).Error("failed to publish token revocations")
+			}
+		},
+	}
+
+	return s, nil
+}
+
+// Publish отправляет отзыв в топик, не дожидаясь подтверждения брокера
+func (s *KafkaSync) Publish(tokenHash string, expiresAt time.Time) {
+	value, err := json.Marshal(event{
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		Origin:    s.origin,
+	})
+	if err != nil {
+		s.log.WithError(err).Error("failed to encode token revocation")
+		return
+	}
+
+	if err := s.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(tokenHash),
+		Value: value,
+	}); err != nil {
+		s.log.WithError(err).Error("failed to publish token revocation")
+	}
+}
+
+// Start читает отзывы других экземпляров из всех партиций до отмены контекста
+func (s *KafkaSync) Start(ctx context.Context) error {
+	defer s.closeWriter()
+
+	partitions, err := s.partitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		wg.Add(1)
+		go func(partition int) {
+			defer wg.Done()
+			s.consume(ctx, partition)
+		}(partition)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// partitions возвращает номера партиций топика, повторяя запрос, пока брокеры недоступны
+func (s *KafkaSync) partitions(ctx context.Context) ([]int, error) {
+	for {
+		var lastErr error
+		for _, broker := range s.brokers {
+			partitions, err := kafka.LookupPartitions(ctx, "tcp", broker, s.topic)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			ids := make([]int, 0, len(partitions))
+			for _, p := range partitions {
+				ids = append(ids, p.ID)
+			}
+			if len(ids) > 0 {
+				return ids, nil
+			}
+			lastErr = fmt.Errorf("topic %s has no partitions", s.topic)
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		s.log.WithError(lastErr).Error("failed to look up token revocation partitions")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// consume читает партицию с первого сохраненного сообщения до отмены контекста
+func (s *KafkaSync) consume(ctx context.Context, partition int) {
+	log := s.log.WithField("partition", partition)
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   s.brokers,
+		Topic:     s.topic,
+		Partition: partition,
+		MaxWait:   time.Second,
+	})
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.WithError(err).Warn("failed to close kafka reader")
+		}
+	}()
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.WithError(err).Error("failed to read token revocations")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		s.handle(msg)
+	}
+}
+
+// handle применяет отзыв из сообщения, пропуская собственные и некорректные события
+func (s *KafkaSync) handle(msg kafka.Message) {
+	var e event
+	if err := json.Unmarshal(msg.Value, &e); err != nil || e.TokenHash == "" {
+		s.log.WithField("partition", msg.Partition).WithField("offset", msg.Offset).Warn("skipping malformed token revocation")
+		return
+	}
+
+	if e.Origin == s.origin {
+		return
+	}
+
+	s.list.add(e.TokenHash, e.ExpiresAt)
+}
+
+func (s *KafkaSync) closeWriter() {
+	if err := s.writer.Close(); err != nil && !errors.Is(err, context.Canceled) {
+		s.log.WithError(err).Warn("failed to close kafka writer")
+	}
+}
@@ -0,0 +1,111 @@
+package revocation
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestSync(t *testing.T, list *List) *KafkaSync {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	s, err := NewKafkaSync(logger, list, []string{"127.0.0.1:9092"}, "revocations")
+	if err != nil {
+		t.Fatalf("NewKafkaSync: %v", err)
+	}
+	t.Cleanup(s.closeWriter)
+
+	return s
+}
+
+func message(t *testing.T, e event) kafka.Message {
+	t.Helper()
+
+	value, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	return kafka.Message{Key: []byte(e.TokenHash), Value: value}
+}
+
+func TestKafkaSyncHandle(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		msg         func(s *KafkaSync) kafka.Message
+		wantRevoked bool
+	}{
+		{
+			name: "other instance",
+			msg: func(s *KafkaSync) kafka.Message {
+				return message(t, event{TokenHash: Hash("token"), ExpiresAt: expiresAt, Origin: "other"})
+			},
+			wantRevoked: true,
+		},
+		{
+			name: "own origin",
+			msg: func(s *KafkaSync) kafka.Message {
+				return message(t, event{TokenHash: Hash("token"), ExpiresAt: expiresAt, Origin: s.origin})
+			},
+		},
+		{
+			name: "missing hash",
+			msg: func(s *KafkaSync) kafka.Message {
+				return message(t, event{ExpiresAt: expiresAt, Origin: "other"})
+			},
+		},
+		{
+			name: "malformed value",
+			msg: func(s *KafkaSync) kafka.Message {
+				return kafka.Message{Value: []byte("not json")}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := NewList(24 * time.Hour)
+			s := newTestSync(t, list)
+
+			s.handle(tt.msg(s))
+
+			if got := list.Revoked("token"); got != tt.wantRevoked {
+				t.Errorf("Revoked() = %v, want %v", got, tt.wantRevoked)
+			}
+			if tt.wantRevoked && list.Len() != 1 {
+				t.Errorf("Len() = %d, want 1", list.Len())
+			}
+		})
+	}
+}
+
+func TestKafkaSyncHandleDoesNotRepublish(t *testing.T) {
+	list := NewList(24 * time.Hour)
+	s := newTestSync(t, list)
+
+	publisher := &countingPublisher{}
+	list.SetPublisher(publisher)
+
+	s.handle(message(t, event{TokenHash: Hash("token"), ExpiresAt: time.Now().Add(time.Hour), Origin: "other"}))
+
+	if publisher.count != 0 {
+		t.Errorf("received revocation was published %d times, want 0", publisher.count)
+	}
+}
+
+type countingPublisher struct {
+	count int
+}
+
+func (p *countingPublisher) Publish(string, time.Time) {
+	p.count++
+}
@@ -0,0 +1,132 @@
+package revocation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+)
+
+const cleanupInterval = time.Minute
+
+// Publisher рассылает отзывы токенов другим экземплярам шлюза
+type Publisher interface {
+	Publish(tokenHash string, expiresAt time.Time)
+}
+
+// List - список отозванных access токенов. Запись хранится до истечения срока
+// действия токена, но не дольше maxTTL, поэтому размер списка ограничен.
+// Токены хранятся в виде SHA-256 хеша (hex), как и в менеджере сессий.
+type List struct {
+	maxTTL    time.Duration
+	publisher Publisher
+
+	mu    sync.RWMutex
+	items map[string]time.Time
+}
+
+func NewList(maxTTL time.Duration) *List {
+	return &List{
+		maxTTL: maxTTL,
+		items:  make(map[string]time.Time),
+	}
+}
+
+// SetPublisher включает рассылку отзывов. Вызывается до начала работы.
+func (l *List) SetPublisher(p Publisher) {
+	l.publisher = p
+}
+
+// Revoke отзывает токен до истечения его срока действия
+func (l *List) Revoke(token string) {
+	var expiresAt time.Time
+	if claims, err := jwt.ParseUnverified(token); err == nil && claims.ExpiresAt > 0 {
+		expiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	l.RevokeHash(Hash(token), expiresAt)
+}
+
+// RevokeHash отзывает токен по хешу. Нулевой expiresAt означает неизвестный
+// срок действия, и запись хранится maxTTL.
+func (l *List) RevokeHash(tokenHash string, expiresAt time.Time) {
+	if l.add(tokenHash, expiresAt) && l.publisher != nil {
+		l.publisher.Publish(tokenHash, expiresAt)
+	}
+}
+
+// Revoked сообщает, отозван ли токен
+func (l *List) Revoked(token string) bool {
+	h := Hash(token)
+
+	l.mu.RLock()
+	expiresAt, ok := l.items[h]
+	l.mu.RUnlock()
+
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Len возвращает число записей в списке
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.items)
+}
+
+// Start периодически удаляет записи об истекших токенах
+func (l *List) Start(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			l.cleanup()
+		}
+	}
+}
+
+// add сохраняет запись и сообщает, появилась ли новая информация
+func (l *List) add(tokenHash string, expiresAt time.Time) bool {
+	now := time.Now()
+	if limit := now.Add(l.maxTTL); expiresAt.IsZero() || expiresAt.After(limit) {
+		expiresAt = limit
+	}
+
+	if !expiresAt.After(now) {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if prev, ok := l.items[tokenHash]; ok && !expiresAt.After(prev) {
+		return false
+	}
+
+	l.items[tokenHash] = expiresAt
+	return true
+}
+
+func (l *List) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for h, expiresAt := range l.items {
+		if now.After(expiresAt) {
+			delete(l.items, h)
+		}
+	}
+}
+
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+package revocation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/markgregr/bestHack_support_REST_server/internal/revocation"
+)
+
+type published struct {
+	tokenHash string
+	expiresAt time.Time
+}
+
+type recordingPublisher struct {
+	events []published
+}
+
+func (p *recordingPublisher) Publish(tokenHash string, expiresAt time.Time) {
+	p.events = append(p.events, published{tokenHash: tokenHash, expiresAt: expiresAt})
+}
+
+func signToken(t *testing.T, expiresAt time.Time) string {
+	t.Helper()
+
+	token, err := jwt.Sign(jwt.Claims{UserID: 1, Email: "user@example.com", ExpiresAt: expiresAt.Unix()}, "secret")
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	return token
+}
+
+func TestRevoke(t *testing.T) {
+	list := revocation.NewList(time.Hour)
+	token := signToken(t, time.Now().Add(10*time.Minute))
+	other := signToken(t, time.Now().Add(20*time.Minute))
+
+	list.Revoke(token)
+
+	if !list.Revoked(token) {
+		t.Error("revoked token is not reported as revoked")
+	}
+	if list.Revoked(other) {
+		t.Error("unrelated token is reported as revoked")
+	}
+	if list.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", list.Len())
+	}
+}
+
+func TestRevokeExpiresWithToken(t *testing.T) {
+	publisher := &recordingPublisher{}
+	list := revocation.NewList(time.Hour)
+	list.SetPublisher(publisher)
+
+	expiresAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	list.Revoke(signToken(t, expiresAt))
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("published %d events, want 1", len(publisher.events))
+	}
+	if !publisher.events[0].expiresAt.Equal(expiresAt) {
+		t.Errorf("published expiresAt = %v, want %v", publisher.events[0].expiresAt, expiresAt)
+	}
+}
+
+func TestRevokeHash(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiresIn   time.Duration
+		wantRevoked bool
+	}{
+		{name: "unknown expiry", expiresIn: 0, wantRevoked: true},
+		{name: "future expiry", expiresIn: time.Minute, wantRevoked: true},
+		{name: "expiry beyond max ttl", expiresIn: 48 * time.Hour, wantRevoked: true},
+		{name: "already expired", expiresIn: -time.Minute, wantRevoked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			list := revocation.NewList(time.Hour)
+			list.SetPublisher(publisher)
+
+			var expiresAt time.Time
+			if tt.expiresIn != 0 {
+				expiresAt = time.Now().Add(tt.expiresIn)
+			}
+			list.RevokeHash(revocation.Hash("token"), expiresAt)
+
+			if got := list.Revoked("token"); got != tt.wantRevoked {
+				t.Errorf("Revoked() = %v, want %v", got, tt.wantRevoked)
+			}
+
+			wantEvents := 0
+			if tt.wantRevoked {
+				wantEvents = 1
+			}
+			if len(publisher.events) != wantEvents {
+				t.Errorf("published %d events, want %d", len(publisher.events), wantEvents)
+			}
+		})
+	}
+}
+
+func TestRevokeHashPublishesOnlyNewInformation(t *testing.T) {
+	publisher := &recordingPublisher{}
+	list := revocation.NewList(time.Hour)
+	list.SetPublisher(publisher)
+
+	h := revocation.Hash("token")
+	now := time.Now()
+
+	list.RevokeHash(h, now.Add(10*time.Minute))
+	list.RevokeHash(h, now.Add(5*time.Minute))
+	list.RevokeHash(h, now.Add(10*time.Minute))
+	list.RevokeHash(h, now.Add(20*time.Minute))
+
+	if len(publisher.events) != 2 {
+		t.Fatalf("published %d events, want 2", len(publisher.events))
+	}
+	if !publisher.events[1].expiresAt.Equal(now.Add(20 * time.Minute)) {
+		t.Errorf("second event expiresAt = %v, want extended expiry", publisher.events[1].expiresAt)
+	}
+}
+
+func TestRevokedExpires(t *testing.T) {
+	list := revocation.NewList(50 * time.Millisecond)
+	list.RevokeHash(revocation.Hash("token"), time.Time{})
+
+	if !list.Revoked("token") {
+		t.Fatal("token is not revoked")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if list.Revoked("token") {
+		t.Error("token is still revoked after max ttl")
+	}
+}
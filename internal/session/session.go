@@ -0,0 +1,337 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const cleanupInterval = time.Minute
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrTokenReused     = errors.New("refresh token reused")
+)
+
+// Session описывает сессию пользователя, выданную шлюзом при логине
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Email      string    `json:"email"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+
+	refreshHash     string
+	accessHash      string
+	accessExpiresAt time.Time
+}
+
+// Meta содержит данные клиента, которые сохраняются вместе с сессией
+type Meta struct {
+	UserID    int64
+	Email     string
+	UserAgent string
+	IP        string
+}
+
+// RevokeHook вызывается при отзыве сессии с SHA-256 хешем (hex) ее текущего
+// access токена, чтобы токен перестал приниматься шлюзом до истечения срока действия.
+// Нулевой expiresAt означает, что срок действия токена неизвестен.
+type RevokeHook func(accessHash string, expiresAt time.Time)
+
+// Manager хранит сессии в памяти и ротирует refresh токены.
+// Каждая сессия - это семейство refresh токенов: при повторном
+// использовании уже обмененного токена сессия отзывается целиком.
+type Manager struct {
+	log        *logrus.Entry
+	refreshTTL time.Duration
+	onRevoke   RevokeHook
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	refresh  map[string]string
+	used     map[string]string
+	access   map[string]string
+}
+
+func NewManager(log *logrus.Logger, refreshTTL time.Duration) *Manager {
+	return &Manager{
+		log:        log.WithField("component", "session.Manager"),
+		refreshTTL: refreshTTL,
+		sessions:   make(map[string]*Session),
+		refresh:    make(map[string]string),
+		used:       make(map[string]string),
+		access:     make(map[string]string),
+	}
+}
+
+// SetRevokeHook задает обработчик отзыва сессий. Вызывается до начала работы.
+func (m *Manager) SetRevokeHook(hook RevokeHook) {
+	m.onRevoke = hook
+}
+
+// Create открывает новую сессию и возвращает refresh токен для нее
+func (m *Manager) Create(meta Meta, accessToken string) (*Session, string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:              id,
+		UserID:          meta.UserID,
+		Email:           meta.Email,
+		UserAgent:       meta.UserAgent,
+		IP:              meta.IP,
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		ExpiresAt:       now.Add(m.refreshTTL),
+		refreshHash:     hash(refreshToken),
+		accessHash:      hash(accessToken),
+		accessExpiresAt: accessExpiry(accessToken),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[s.ID] = s
+	m.refresh[s.refreshHash] = s.ID
+	m.access[s.accessHash] = s.ID
+
+	return s, refreshToken, nil
+}
+
+// Rotate обменивает refresh токен на новый, а issue выпускает для сессии
+// новый access token. Повторное предъявление
+// уже обмененного токена отзывает всю сессию.
+func (m *Manager) Rotate(refreshToken string, issue func(s *Session) (string, error)) (*Session, string, error) {
+	h := hash(refreshToken)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.used[h]; ok {
+		m.log.WithField("session_id", id).Warn("refresh token reuse detected, revoking session")
+		m.revoke(id)
+		return nil, "", ErrTokenReused
+	}
+
+	id, ok := m.refresh[h]
+	if !ok {
+		return nil, "", ErrSessionNotFound
+	}
+
+	s := m.sessions[id]
+	if time.Now().After(s.ExpiresAt) {
+		m.revoke(id)
+		return nil, "", ErrSessionNotFound
+	}
+
+	newRefresh, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	accessToken, err := issue(s)
+	if err != nil {
+		return nil, "", err
+	}
+
+	delete(m.refresh, h)
+	m.used[h] = id
+	delete(m.access, s.accessHash)
+
+	s.refreshHash = hash(newRefresh)
+	s.accessHash = hash(accessToken)
+	s.accessExpiresAt = accessExpiry(accessToken)
+	s.LastUsedAt = time.Now()
+
+	m.refresh[s.refreshHash] = id
+	m.access[s.accessHash] = id
+
+	return s, newRefresh, nil
+}
+
+// RevokeByRefreshToken отзывает сессию, которой принадлежит refresh токен
+func (m *Manager) RevokeByRefreshToken(refreshToken string) bool {
+	h := hash(refreshToken)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, ok := m.refresh[h]
+	if !ok {
+		return false
+	}
+
+	m.revoke(id)
+	return true
+}
+
+// RevokeByAccessToken отзывает сессию, в рамках которой был выдан access токен
+func (m *Manager) RevokeByAccessToken(accessToken string) bool {
+	h := hash(accessToken)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, ok := m.access[h]
+	if !ok {
+		return false
+	}
+
+	m.revoke(id)
+	return true
+}
+
+// List возвращает активные сессии пользователя, начиная с самой новой
+func (m *Manager) List(userID int64) []*Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*Session, 0)
+	for _, s := range m.sessions {
+		if s.UserID != userID || now.After(s.ExpiresAt) {
+			continue
+		}
+
+		c := *s
+		sessions = append(sessions, &c)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions
+}
+
+// IDByAccessToken возвращает идентификатор сессии, в рамках которой выдан access токен
+func (m *Manager) IDByAccessToken(accessToken string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.access[hash(accessToken)]
+}
+
+// Revoke отзывает сессию пользователя по идентификатору
+func (m *Manager) Revoke(userID int64, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	m.revoke(id)
+	return nil
+}
+
+// RevokeAll отзывает все сессии пользователя, кроме exceptID, и возвращает их число
+func (m *Manager) RevokeAll(userID int64, exceptID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var revoked int
+	for id, s := range m.sessions {
+		if s.UserID == userID && id != exceptID {
+			m.revoke(id)
+			revoked++
+		}
+	}
+
+	return revoked
+}
+
+// Start периодически удаляет истекшие сессии
+func (m *Manager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
+
+func (m *Manager) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, s := range m.sessions {
+		if now.After(s.ExpiresAt) {
+			m.revoke(id)
+		}
+	}
+}
+
+func (m *Manager) revoke(id string) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+
+	delete(m.sessions, id)
+	delete(m.refresh, s.refreshHash)
+	delete(m.access, s.accessHash)
+
+	if m.onRevoke != nil {
+		m.onRevoke(s.accessHash, s.accessExpiresAt)
+	}
+
+	for h, sid := range m.used {
+		if sid == id {
+			delete(m.used, h)
+		}
+	}
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// accessExpiry возвращает срок действия access токена. Токен получен от SSO
+// или выпущен шлюзом, поэтому подпись здесь не проверяется.
+func accessExpiry(accessToken string) time.Time {
+	claims, err := jwt.ParseUnverified(accessToken)
+	if err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
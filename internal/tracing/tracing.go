@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/config"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName - имя шлюза в трассах
+const ServiceName = "support-rest-server"
+
+// Provider экспортирует спаны в OTLP коллектор
+type Provider struct {
+	log      *logrus.Entry
+	cfg      *config.Tracing
+	provider *sdktrace.TracerProvider
+}
+
+// New настраивает глобальные TracerProvider и propagator W3C Trace Context.
+// Пока New не вызван, инструментирование создает только неэкспортируемые спаны.
+func New(ctx context.Context, log *logrus.Entry, cfg *config.Tracing, env string) (*Provider, error) {
+	const op = "tracing.New"
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("%s: sample ratio must be between 0 and 1, got %v", op, cfg.SampleRatio)
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+		otlptracegrpc.WithTimeout(cfg.ExportTimeout),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	// Экспортер подключается лениво, поэтому недоступный коллектор не мешает старту
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: create exporter: %w", op, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.DeploymentEnvironment(env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("%s: build resource: %w", op, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.WithError(err).Warn("opentelemetry error")
+	}))
+
+	return &Provider{
+		log:      log,
+		cfg:      cfg,
+		provider: provider,
+	}, nil
+}
+
+// Start ждет остановки приложения и отправляет накопленные спаны
+func (p *Provider) Start(ctx context.Context) error {
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), p.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := p.provider.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.Canceled) {
+		p.log.WithError(err).Error("failed to flush spans")
+	}
+
+	return ctx.Err()
+}
+
+// HTTPClient возвращает клиент, создающий клиентские спаны и передающий traceparent
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+}
+
+// Tracer возвращает трейсер шлюза из глобального провайдера
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
@@ -0,0 +1,112 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// maxChallenges ограничивает число незавершенных входов в памяти
+const maxChallenges = 10000
+
+var (
+	ErrChallengeNotFound = errors.New("two-factor challenge not found or expired")
+	ErrTooManyChallenges = errors.New("too many pending two-factor challenges")
+)
+
+// Challenge - вход, прошедший проверку пароля и ожидающий второй фактор.
+// Токен SSO хранится только на стороне шлюза и выдается после проверки кода.
+type Challenge struct {
+	UserID      int64
+	Email       string
+	AccessToken string
+
+	attempts  int
+	expiresAt time.Time
+}
+
+type Challenges struct {
+	ttl         time.Duration
+	maxAttempts int
+
+	mu    sync.Mutex
+	items map[string]*Challenge
+}
+
+func NewChallenges(ttl time.Duration, maxAttempts int) *Challenges {
+	return &Challenges{
+		ttl:         ttl,
+		maxAttempts: maxAttempts,
+		items:       make(map[string]*Challenge),
+	}
+}
+
+// Issue сохраняет вход и возвращает токен для второго шага и время его истечения
+func (c *Challenges) Issue(challenge Challenge) (string, time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	challenge.expiresAt = time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.items) >= maxChallenges {
+		c.sweep()
+		if len(c.items) >= maxChallenges {
+			return "", time.Time{}, ErrTooManyChallenges
+		}
+	}
+
+	c.items[token] = &challenge
+
+	return token, challenge.expiresAt, nil
+}
+
+// Complete проверяет второй фактор функцией verify. При успехе вход удаляется,
+// при ошибке увеличивается счетчик попыток, а после maxAttempts вход отменяется.
+func (c *Challenges) Complete(token string, verify func(*Challenge) error) (*Challenge, error) {
+	c.mu.Lock()
+	challenge, ok := c.items[token]
+	if !ok || time.Now().After(challenge.expiresAt) {
+		delete(c.items, token)
+		c.mu.Unlock()
+		return nil, ErrChallengeNotFound
+	}
+	c.mu.Unlock()
+
+	if err := verify(challenge); err != nil {
+		c.mu.Lock()
+		challenge.attempts++
+		if challenge.attempts >= c.maxAttempts {
+			delete(c.items, token)
+		}
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Параллельный запрос с тем же токеном мог завершить вход раньше
+	if _, ok := c.items[token]; !ok {
+		return nil, ErrChallengeNotFound
+	}
+	delete(c.items, token)
+
+	return challenge, nil
+}
+
+func (c *Challenges) sweep() {
+	now := time.Now()
+	for token, challenge := range c.items {
+		if now.After(challenge.expiresAt) {
+			delete(c.items, token)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package twofactor_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+)
+
+var errWrongCode = errors.New("wrong code")
+
+func TestChallengeLockoutAfterMaxAttempts(t *testing.T) {
+	challenges := twofactor.NewChallenges(time.Minute, 3)
+
+	token, _, err := challenges.Issue(twofactor.Challenge{UserID: userID, AccessToken: "access"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	fail := func(*twofactor.Challenge) error { return errWrongCode }
+	pass := func(*twofactor.Challenge) error { return nil }
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if _, err := challenges.Complete(token, fail); !errors.Is(err, errWrongCode) {
+			t.Fatalf("attempt %d: Complete = %v, want %v", attempt, err, errWrongCode)
+		}
+	}
+
+	if _, err := challenges.Complete(token, pass); !errors.Is(err, twofactor.ErrChallengeNotFound) {
+		t.Errorf("Complete after max attempts = %v, want %v", err, twofactor.ErrChallengeNotFound)
+	}
+}
+
+func TestChallengeCompletesOnce(t *testing.T) {
+	challenges := twofactor.NewChallenges(time.Minute, 3)
+
+	token, _, err := challenges.Issue(twofactor.Challenge{UserID: userID, AccessToken: "access"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	pass := func(*twofactor.Challenge) error { return nil }
+
+	challenge, err := challenges.Complete(token, pass)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if challenge.UserID != userID || challenge.AccessToken != "access" {
+		t.Errorf("Complete = %+v, want issued challenge", challenge)
+	}
+
+	if _, err := challenges.Complete(token, pass); !errors.Is(err, twofactor.ErrChallengeNotFound) {
+		t.Errorf("second Complete = %v, want %v", err, twofactor.ErrChallengeNotFound)
+	}
+	if _, err := challenges.Complete("unknown", pass); !errors.Is(err, twofactor.ErrChallengeNotFound) {
+		t.Errorf("Complete with unknown token = %v, want %v", err, twofactor.ErrChallengeNotFound)
+	}
+}
+
+func TestChallengeExpires(t *testing.T) {
+	challenges := twofactor.NewChallenges(time.Millisecond, 3)
+
+	token, _, err := challenges.Issue(twofactor.Challenge{UserID: userID})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := challenges.Complete(token, func(*twofactor.Challenge) error { return nil }); !errors.Is(err, twofactor.ErrChallengeNotFound) {
+		t.Errorf("Complete after expiry = %v, want %v", err, twofactor.ErrChallengeNotFound)
+	}
+}
@@ -0,0 +1,348 @@
+package twofactor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/filestore"
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+	// codeSkew допускает расхождение часов телефона на один интервал
+	codeSkew = 1
+)
+
+var (
+	ErrNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrNotConfirmed   = errors.New("two-factor enrolment is not confirmed")
+	ErrInvalidCode    = errors.New("invalid two-factor code")
+)
+
+type enrollment struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+	// Secret зашифрован ключом, производным от секрета приложения
+	Secret        string     `json:"secret"`
+	Confirmed     bool       `json:"confirmed"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodes []string   `json:"recovery_codes,omitempty"`
+	// LastStep - интервал последнего принятого кода, повторно код не принимается
+	LastStep int64 `json:"last_step"`
+}
+
+// Store хранит TOTP секреты и хеши кодов восстановления в JSON файле
+type Store struct {
+	path   string
+	issuer string
+	aead   cipher.AEAD
+
+	mu          sync.Mutex
+	enrollments map[int64]*enrollment
+}
+
+func NewStore(path, issuer, appSecret string) (*Store, error) {
+	key := sha256.Sum256([]byte("twofactor:" + appSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path:        path,
+		issuer:      issuer,
+		aead:        aead,
+		enrollments: make(map[int64]*enrollment),
+	}
+
+	var enrollments []*enrollment
+	if err := filestore.Load(path, &enrollments); err != nil {
+		return nil, err
+	}
+
+	for _, e := range enrollments {
+		s.enrollments[e.UserID] = e
+	}
+
+	return s, nil
+}
+
+// Enabled сообщает, включена ли у пользователя двухфакторная аутентификация
+func (s *Store) Enabled(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	return ok && e.Confirmed
+}
+
+// Enroll создает новый секрет и возвращает его вместе с provisioning URI.
+// До подтверждения кодом вход пользователя остается однофакторным.
+func (s *Store) Enroll(userID int64, email string) (string, string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := s.encrypt(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.enrollments[userID]
+	if ok && prev.Confirmed {
+		return "", "", ErrAlreadyEnabled
+	}
+
+	s.enrollments[userID] = &enrollment{
+		UserID:    userID,
+		Email:     email,
+		Secret:    encrypted,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.save(); err != nil {
+		s.restore(userID, prev)
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(s.issuer, email, secret), nil
+}
+
+// Confirm включает двухфакторную аутентификацию после проверки первого кода
+// и возвращает коды восстановления. Коды показываются только один раз.
+func (s *Store) Confirm(userID int64, code string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return nil, ErrNotEnrolled
+	}
+
+	if e.Confirmed {
+		return nil, ErrAlreadyEnabled
+	}
+
+	step, err := s.validate(e, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	prev := *e
+	now := time.Now()
+	e.Confirmed = true
+	e.ConfirmedAt = &now
+	e.RecoveryCodes = hashes
+	e.LastStep = step
+
+	if err := s.save(); err != nil {
+		*e = prev
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify проверяет TOTP код или одноразовый код восстановления
+func (s *Store) Verify(userID int64, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return ErrNotEnrolled
+	}
+
+	if !e.Confirmed {
+		return ErrNotConfirmed
+	}
+
+	prev := *e
+	prev.RecoveryCodes = append([]string(nil), e.RecoveryCodes...)
+
+	if step, err := s.validate(e, code); err == nil {
+		e.LastStep = step
+	} else if !e.useRecoveryCode(code) {
+		return ErrInvalidCode
+	}
+
+	if err := s.save(); err != nil {
+		*e = prev
+		return err
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки кода
+func (s *Store) RegenerateRecoveryCodes(userID int64, code string) ([]string, error) {
+	if err := s.Verify(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return nil, ErrNotEnrolled
+	}
+
+	prev := e.RecoveryCodes
+	e.RecoveryCodes = hashes
+	if err := s.save(); err != nil {
+		e.RecoveryCodes = prev
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Reset удаляет двухфакторную аутентификацию пользователя
+func (s *Store) Reset(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.enrollments[userID]
+	if !ok {
+		return ErrNotEnrolled
+	}
+
+	delete(s.enrollments, userID)
+	if err := s.save(); err != nil {
+		s.enrollments[userID] = prev
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) validate(e *enrollment, code string) (int64, error) {
+	secret, err := s.decrypt(e.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now(), codeSkew)
+	if !ok || step <= e.LastStep {
+		return 0, ErrInvalidCode
+	}
+
+	return step, nil
+}
+
+func (e *enrollment) useRecoveryCode(code string) bool {
+	h := hashRecoveryCode(code)
+	for i, stored := range e.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(h)) == 1 {
+			e.RecoveryCodes = append(e.RecoveryCodes[:i:i], e.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Store) restore(userID int64, prev *enrollment) {
+	if prev != nil {
+		s.enrollments[userID] = prev
+	} else {
+		delete(s.enrollments, userID)
+	}
+}
+
+func (s *Store) encrypt(plain string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func (s *Store) decrypt(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", fmt.Errorf("decode two-factor secret: malformed value")
+	}
+
+	plain, err := s.aead.Open(nil, data[:s.aead.NonceSize()], data[s.aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt two-factor secret: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+func (s *Store) save() error {
+	enrollments := make([]*enrollment, 0, len(s.enrollments))
+	for _, e := range s.enrollments {
+		enrollments = append(enrollments, e)
+	}
+
+	if err := filestore.Save(s.path, enrollments); err != nil {
+		return fmt.Errorf("save two-factor enrollments: %w", err)
+	}
+
+	return nil
+}
+
+// newRecoveryCodes возвращает коды вида xxxxx-xxxxx и их хеши для хранения
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
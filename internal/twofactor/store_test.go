@@ -0,0 +1,177 @@
+package twofactor_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/internal/lib/totp"
+	"github.com/markgregr/bestHack_support_REST_server/internal/twofactor"
+)
+
+const userID = 42
+
+func newStore(t *testing.T) *twofactor.Store {
+	t.Helper()
+
+	store, err := twofactor.NewStore(filepath.Join(t.TempDir(), "two_factor.json"), "Support", "app-secret")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	return store
+}
+
+// enroll включает 2FA и возвращает секрет, интервал подтверждения и коды восстановления
+func enroll(t *testing.T, store *twofactor.Store) (string, int64, []string) {
+	t.Helper()
+
+	secret, _, err := store.Enroll(userID, "user@example.com")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+
+	step := totp.Step(time.Now())
+	recoveryCodes, err := store.Confirm(userID, code(t, secret, step))
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	return secret, step, recoveryCodes
+}
+
+func code(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	c, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+
+	return c
+}
+
+func TestEnrollAndConfirm(t *testing.T) {
+	store := newStore(t)
+
+	secret, uri, err := store.Enroll(userID, "user@example.com")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if secret == "" || uri == "" {
+		t.Fatalf("Enroll returned secret %q and uri %q", secret, uri)
+	}
+	if store.Enabled(userID) {
+		t.Errorf("Enabled before confirmation")
+	}
+	if err := store.Verify(userID, code(t, secret, totp.Step(time.Now()))); !errors.Is(err, twofactor.ErrNotConfirmed) {
+		t.Errorf("Verify before confirmation = %v, want %v", err, twofactor.ErrNotConfirmed)
+	}
+
+	// Код через пять интервалов выходит за допуск расхождения часов
+	if _, err := store.Confirm(userID, code(t, secret, totp.Step(time.Now())+5)); !errors.Is(err, twofactor.ErrInvalidCode) {
+		t.Errorf("Confirm with wrong code = %v, want %v", err, twofactor.ErrInvalidCode)
+	}
+
+	recoveryCodes, err := store.Confirm(userID, code(t, secret, totp.Step(time.Now())))
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(recoveryCodes) != 10 {
+		t.Errorf("recovery codes = %d, want 10", len(recoveryCodes))
+	}
+	if !store.Enabled(userID) {
+		t.Errorf("Enabled after confirmation = false")
+	}
+
+	if _, _, err := store.Enroll(userID, "user@example.com"); !errors.Is(err, twofactor.ErrAlreadyEnabled) {
+		t.Errorf("Enroll when enabled = %v, want %v", err, twofactor.ErrAlreadyEnabled)
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	store := newStore(t)
+	secret, step, _ := enroll(t, store)
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{"code used for confirmation", code(t, secret, step), twofactor.ErrInvalidCode},
+		{"code older than last step", code(t, secret, step-1), twofactor.ErrInvalidCode},
+		{"next step", code(t, secret, step+1), nil},
+		{"next step replayed", code(t, secret, step+1), twofactor.ErrInvalidCode},
+	}
+
+	for _, tt := range tests {
+		if err := store.Verify(userID, tt.code); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRecoveryCodesAreOneTime(t *testing.T) {
+	store := newStore(t)
+	secret, step, recoveryCodes := enroll(t, store)
+
+	if err := store.Verify(userID, recoveryCodes[0]); err != nil {
+		t.Fatalf("Verify with recovery code: %v", err)
+	}
+	if err := store.Verify(userID, recoveryCodes[0]); !errors.Is(err, twofactor.ErrInvalidCode) {
+		t.Errorf("Verify with used recovery code = %v, want %v", err, twofactor.ErrInvalidCode)
+	}
+	// Коды сравниваются без учета регистра и дефиса
+	if err := store.Verify(userID, " "+strings.ToUpper(strings.ReplaceAll(recoveryCodes[1], "-", ""))+" "); err != nil {
+		t.Errorf("Verify with normalized recovery code: %v", err)
+	}
+
+	regenerated, err := store.RegenerateRecoveryCodes(userID, code(t, secret, step+1))
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+	if err := store.Verify(userID, recoveryCodes[2]); !errors.Is(err, twofactor.ErrInvalidCode) {
+		t.Errorf("Verify with replaced recovery code = %v, want %v", err, twofactor.ErrInvalidCode)
+	}
+	if err := store.Verify(userID, regenerated[0]); err != nil {
+		t.Errorf("Verify with regenerated recovery code: %v", err)
+	}
+}
+
+func TestStorePersistsEnrollment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "two_factor.json")
+	store, err := twofactor.NewStore(path, "Support", "app-secret")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	secret, step, _ := enroll(t, store)
+
+	reloaded, err := twofactor.NewStore(path, "Support", "app-secret")
+	if err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	if !reloaded.Enabled(userID) {
+		t.Fatalf("Enabled after reload = false")
+	}
+	if err := reloaded.Verify(userID, code(t, secret, step)); !errors.Is(err, twofactor.ErrInvalidCode) {
+		t.Errorf("Verify replay after reload = %v, want %v", err, twofactor.ErrInvalidCode)
+	}
+
+	// Секрет зашифрован ключом приложения, с другим ключом код не проходит проверку
+	otherKey, err := twofactor.NewStore(path, "Support", "other-secret")
+	if err != nil {
+		t.Fatalf("reload store with other key: %v", err)
+	}
+	if err := otherKey.Verify(userID, code(t, secret, step+1)); err == nil {
+		t.Errorf("Verify with other app secret: want error")
+	}
+
+	if err := reloaded.Reset(userID); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if err := reloaded.Verify(userID, code(t, secret, step+1)); !errors.Is(err, twofactor.ErrNotEnrolled) {
+		t.Errorf("Verify after reset = %v, want %v", err, twofactor.ErrNotEnrolled)
+	}
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var requestsCounter *prometheus.CounterVec
+
+func init() {
+	requestsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "How many cache lookups were made, partitioned by cache name and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	if err := prometheus.Register(requestsCounter); err != nil {
+		log.WithError(err).
+			WithField("metric", "cache_requests_total").
+			Error("unable to register prometheus metric")
+	}
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// Cache - потокобезопасный кэш с TTL и ограничением числа записей.
+// При переполнении вытесняется давно не использованная запись.
+// Generation меняется при каждой очистке, что позволяет не сохранять
+// значение, прочитанное до инвалидации.
+type Cache[K comparable, V any] struct {
+	name       string
+	ttl        time.Duration
+	maxEntries int
+
+	mu         sync.Mutex
+	items      map[K]*list.Element
+	order      *list.List
+	generation uint64
+}
+
+func New[K comparable, V any](name string, ttl time.Duration, maxEntries int) *Cache[K, V] {
+	return &Cache[K, V]{
+		name:       name,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      make(map[K]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if time.Now().Before(e.expiresAt) {
+			c.order.MoveToFront(el)
+			requestsCounter.WithLabelValues(c.name, "hit").Inc()
+			return e.value, true
+		}
+		c.remove(el)
+	}
+
+	requestsCounter.WithLabelValues(c.name, "miss").Inc()
+
+	var zero V
+	return zero, false
+}
+
+// Generation возвращает текущее поколение кэша для последующего Set
+func (c *Cache[K, V]) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// Set сохраняет значение, если с момента получения generation кэш не очищался
+func (c *Cache[K, V]) Set(generation uint64, key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Purge удаляет все записи и начинает новое поколение
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+	c.generation++
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,187 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// halfOpenRetryAfter - подсказка клиенту, когда все пробные запросы полуоткрытого
+// автомата уже заняты
+const halfOpenRetryAfter = time.Second
+
+// OpenError возвращается вместо вызова, пока автомат разомкнут
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %q is open", e.Name)
+}
+
+type Settings struct {
+	// FailureThreshold - число неудач подряд, после которого автомат размыкается
+	FailureThreshold uint
+	// SuccessThreshold - число успешных пробных вызовов для замыкания
+	SuccessThreshold uint
+	// OpenTimeout - время в разомкнутом состоянии до перехода в полуоткрытое
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests - число одновременных пробных вызовов в полуоткрытом состоянии
+	HalfOpenMaxRequests uint
+	// OnStateChange вызывается при каждом переходе под блокировкой автомата
+	OnStateChange func(name string, from, to State)
+}
+
+// Breaker - автомат с состояниями closed, open и half-open. В закрытом состоянии
+// считает неудачи подряд, в разомкнутом отклоняет вызовы до истечения OpenTimeout,
+// в полуоткрытом пропускает ограниченное число пробных вызовов.
+type Breaker struct {
+	name     string
+	settings Settings
+
+	mu        sync.Mutex
+	state     State
+	failures  uint
+	successes uint
+	inFlight  uint
+	openedAt  time.Time
+	// generation растет при каждом переходе и отделяет вызовы текущего состояния от прежних
+	generation uint64
+}
+
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold == 0 {
+		settings.FailureThreshold = 1
+	}
+	if settings.SuccessThreshold == 0 {
+		settings.SuccessThreshold = 1
+	}
+	if settings.HalfOpenMaxRequests == 0 {
+		settings.HalfOpenMaxRequests = 1
+	}
+
+	return &Breaker{
+		name:     name,
+		settings: settings,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.state
+}
+
+// Allow разрешает вызов или возвращает *OpenError. После вызова нужно
+// сообщить его результат через done.
+func (b *Breaker) Allow() (done func(failed bool), err error) {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(now)
+
+	switch b.state {
+	case StateOpen:
+		return nil, &OpenError{Name: b.name, RetryAfter: b.openedAt.Add(b.settings.OpenTimeout).Sub(now)}
+	case StateHalfOpen:
+		if b.inFlight >= b.settings.HalfOpenMaxRequests {
+			return nil, &OpenError{Name: b.name, RetryAfter: halfOpenRetryAfter}
+		}
+		b.inFlight++
+	}
+
+	generation := b.generation
+	return func(failed bool) {
+		b.done(generation, failed)
+	}, nil
+}
+
+func (b *Breaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Результат вызова, начатого до последнего перехода, уже не влияет на автомат,
+	// в том числе на число пробных вызовов следующего полуоткрытого состояния
+	if generation != b.generation {
+		return
+	}
+
+	if b.state == StateHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(StateOpen, time.Now())
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, time.Now())
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.SuccessThreshold {
+			b.setState(StateClosed, time.Now())
+		}
+	}
+}
+
+// refresh переводит разомкнутый автомат в полуоткрытое состояние по истечении OpenTimeout
+func (b *Breaker) refresh(now time.Time) {
+	if b.state == StateOpen && !now.Before(b.openedAt.Add(b.settings.OpenTimeout)) {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.name, from, state)
+	}
+}
@@ -0,0 +1,154 @@
+package circuitbreaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/circuitbreaker"
+)
+
+const openTimeout = 20 * time.Millisecond
+
+type transition struct {
+	from, to circuitbreaker.State
+}
+
+func newBreaker(t *testing.T, settings circuitbreaker.Settings) (*circuitbreaker.Breaker, *[]transition) {
+	t.Helper()
+
+	var transitions []transition
+	settings.OpenTimeout = openTimeout
+	settings.OnStateChange = func(_ string, from, to circuitbreaker.State) {
+		transitions = append(transitions, transition{from: from, to: to})
+	}
+
+	return circuitbreaker.New("tasks", settings), &transitions
+}
+
+func allow(t *testing.T, b *circuitbreaker.Breaker) func(failed bool) {
+	t.Helper()
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() in state %s: %v", b.State(), err)
+	}
+
+	return done
+}
+
+func expectRejected(t *testing.T, b *circuitbreaker.Breaker) {
+	t.Helper()
+
+	_, err := b.Allow()
+	var openErr *circuitbreaker.OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Allow() in state %s: error = %v, want *OpenError", b.State(), err)
+	}
+}
+
+func expectState(t *testing.T, b *circuitbreaker.Breaker, want circuitbreaker.State) {
+	t.Helper()
+
+	if got := b.State(); got != want {
+		t.Fatalf("State() = %s, want %s", got, want)
+	}
+}
+
+// waitHalfOpen ждет перехода разомкнутого автомата в полуоткрытое состояние
+func waitHalfOpen(t *testing.T, b *circuitbreaker.Breaker) {
+	t.Helper()
+
+	time.Sleep(openTimeout + 5*time.Millisecond)
+	expectState(t, b, circuitbreaker.StateHalfOpen)
+}
+
+func TestBreakerLifecycle(t *testing.T) {
+	b, transitions := newBreaker(t, circuitbreaker.Settings{
+		FailureThreshold:    2,
+		SuccessThreshold:    2,
+		HalfOpenMaxRequests: 2,
+	})
+
+	allow(t, b)(true)
+	allow(t, b)(false)
+	allow(t, b)(true)
+	expectState(t, b, circuitbreaker.StateClosed)
+
+	allow(t, b)(true)
+	expectState(t, b, circuitbreaker.StateOpen)
+	expectRejected(t, b)
+
+	waitHalfOpen(t, b)
+	first := allow(t, b)
+	second := allow(t, b)
+	expectRejected(t, b)
+
+	first(false)
+	expectState(t, b, circuitbreaker.StateHalfOpen)
+	second(false)
+	expectState(t, b, circuitbreaker.StateClosed)
+
+	want := []transition{
+		{from: circuitbreaker.StateClosed, to: circuitbreaker.StateOpen},
+		{from: circuitbreaker.StateOpen, to: circuitbreaker.StateHalfOpen},
+		{from: circuitbreaker.StateHalfOpen, to: circuitbreaker.StateClosed},
+	}
+	if len(*transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", *transitions, want)
+	}
+	for i := range want {
+		if (*transitions)[i] != want[i] {
+			t.Errorf("transition %d = %v, want %v", i, (*transitions)[i], want[i])
+		}
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b, _ := newBreaker(t, circuitbreaker.Settings{FailureThreshold: 1})
+
+	allow(t, b)(true)
+	waitHalfOpen(t, b)
+
+	allow(t, b)(true)
+	expectState(t, b, circuitbreaker.StateOpen)
+	expectRejected(t, b)
+}
+
+func TestBreakerIgnoresStaleCallbacks(t *testing.T) {
+	b, _ := newBreaker(t, circuitbreaker.Settings{
+		FailureThreshold:    1,
+		SuccessThreshold:    1,
+		HalfOpenMaxRequests: 2,
+	})
+
+	allow(t, b)(true)
+	waitHalfOpen(t, b)
+
+	failing := allow(t, b)
+	stale := allow(t, b)
+	failing(true)
+	expectState(t, b, circuitbreaker.StateOpen)
+
+	waitHalfOpen(t, b)
+	allow(t, b)
+	allow(t, b)
+
+	// Пробный вызов прошлого полуоткрытого состояния не освобождает место
+	// и не замыкает автомат
+	stale(false)
+	expectState(t, b, circuitbreaker.StateHalfOpen)
+	expectRejected(t, b)
+}
+
+func TestBreakerIgnoresCallsStartedBeforeOpening(t *testing.T) {
+	b, _ := newBreaker(t, circuitbreaker.Settings{FailureThreshold: 1})
+
+	slow := allow(t, b)
+	allow(t, b)(true)
+	expectState(t, b, circuitbreaker.StateOpen)
+
+	waitHalfOpen(t, b)
+	slow(true)
+	expectState(t, b, circuitbreaker.StateHalfOpen)
+}
@@ -0,0 +1,115 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Rule ограничивает доступ к маршрутам с префиксом Prefix (и методом Method, если он задан).
+// Формат: "[<METHOD> ]<PREFIX>=<allow|deny>:<CIDR>[,<CIDR>...]",
+// например "PUT /cluster=allow:10.0.0.0/8" или "/user=deny:192.0.2.0/24".
+type Rule struct {
+	Method string
+	Prefix string
+	Allow  bool
+	Nets   []*net.IPNet
+}
+
+func ParseRule(s string) (Rule, error) {
+	route, acl, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid ip rule %q: missing '='", s)
+	}
+
+	var rule Rule
+	route = strings.TrimSpace(route)
+	if method, prefix, ok := strings.Cut(route, " "); ok {
+		rule.Method = strings.ToUpper(method)
+		route = strings.TrimSpace(prefix)
+	}
+	rule.Prefix = strings.TrimSuffix(route, "/")
+
+	action, cidrs, ok := strings.Cut(acl, ":")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid ip rule %q: expected '<allow|deny>:<CIDR>'", s)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "allow":
+		rule.Allow = true
+	case "deny":
+		rule.Allow = false
+	default:
+		return Rule{}, fmt.Errorf("invalid ip rule %q: unknown action %q", s, action)
+	}
+
+	for _, cidr := range strings.Split(cidrs, ",") {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid ip rule %q: %w", s, err)
+		}
+		rule.Nets = append(rule.Nets, n)
+	}
+
+	return rule, nil
+}
+
+func (r Rule) matches(method, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+
+	return path == r.Prefix || strings.HasPrefix(path, r.Prefix+"/") || r.Prefix == ""
+}
+
+func (r Rule) contains(ip net.IP) bool {
+	for _, n := range r.Nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter применяет правила ко всем подходящим маршрутам: адрес из deny списка
+// отклоняется, а при наличии allow правил адрес должен входить в каждое из них
+type Filter struct {
+	rules []Rule
+}
+
+func New(rules []string) (*Filter, error) {
+	f := &Filter{}
+	for _, r := range rules {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+
+		rule, err := ParseRule(r)
+		if err != nil {
+			return nil, err
+		}
+		f.rules = append(f.rules, rule)
+	}
+
+	return f, nil
+}
+
+func (f *Filter) Empty() bool {
+	return len(f.rules) == 0
+}
+
+func (f *Filter) Allowed(method, path string, ip net.IP) bool {
+	for _, rule := range f.rules {
+		if !rule.matches(method, path) {
+			continue
+		}
+
+		if ip == nil || rule.contains(ip) != rule.Allow {
+			return false
+		}
+	}
+
+	return true
+}
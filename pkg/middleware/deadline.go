@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline возвращает Gin middleware, ограничивающий время обработки запроса.
+// Таймаут берется из routes по ключу "<METHOD> <PATH>", иначе используется timeout.
+// Контекст запроса отменяется и при отключении клиента, и по истечении дедлайна.
+func Deadline(timeout time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		d, ok := routes[c.Request.Method+" "+path]
+		if !ok {
+			d = timeout
+		}
+
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
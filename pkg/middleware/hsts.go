@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HSTS возвращает Gin middleware, выставляющий заголовок Strict-Transport-Security
+func HSTS(maxAge time.Duration, includeSubdomains bool) gin.HandlerFunc {
+	value := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Strict-Transport-Security", value)
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ipfilter"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+)
+
+// IPFilter возвращает Gin middleware, отклоняющий запросы с адресов,
+// которым правила filter запрещают доступ к маршруту
+func IPFilter(logger *log.Logger, filter *ipfilter.Filter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		var ip net.IP
+		if addr := helper.GetRemoteAddr(c.Request); addr != nil {
+			ip = net.ParseIP(*addr)
+		}
+
+		if !filter.Allowed(c.Request.Method, path, ip) {
+			logger.WithContext(c.Request.Context()).WithField("ip", ip.String()).WithField("route", c.Request.Method+" "+path).Warn("request rejected by ip filter")
+			response.HandleError(response.NewForbiddenError(), c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
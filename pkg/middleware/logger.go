@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	ginlogrus "github.com/Toorop/gin-logrus"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Logger возвращает Gin middleware access лога. Запись создается с контекстом
+// запроса, поэтому содержит request_id, если он выставлен middleware RequestID.
+func Logger(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ginlogrus.Logger(logger.WithContext(c.Request.Context()))(c)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Metadata возвращает Gin middleware, сохраняющий в контексте запроса сведения,
+// которые gRPC клиент передает бэкенду: идентификатор запроса, IP и user agent
+// клиента, версию API и контекст трассировки W3C. Если запрос уже трассируется
+// OpenTelemetry, traceparent передает инструментирование gRPC клиента.
+func Metadata(apiVersion string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		md := &reqmeta.Metadata{
+			RequestID:  helper.GetRequestID(c),
+			UserAgent:  c.Request.UserAgent(),
+			APIVersion: apiVersion,
+		}
+		if !trace.SpanContextFromContext(c.Request.Context()).IsValid() {
+			md.TraceParent = reqmeta.ChildTraceParent(c.GetHeader(reqmeta.TraceParentKey))
+			md.TraceState = c.GetHeader(reqmeta.TraceStateKey)
+		}
+		if ip := helper.GetRemoteAddr(c.Request); ip != nil {
+			md.ClientIP = *ip
+		}
+
+		c.Request = c.Request.WithContext(reqmeta.NewContext(c.Request.Context(), md))
+		c.Next()
+	}
+}
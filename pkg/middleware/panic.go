@@ -27,7 +27,7 @@ func Panic(log *logrus.Logger) gin.HandlerFunc {
 
 				body, _ := ioutil.ReadAll(c.Request.Body)
 
-				log.WithFields(logrus.Fields{
+				log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
 					"message": message,
 					"request": fmt.Sprintf("%s %s?%s", c.Request.Method, c.Request.URL.String(), c.Request.URL.Query().Encode()),
 					"body":    string(body),
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+)
+
+// RateLimitKeys возвращает ключи, по которым ограничивается запрос,
+// например IP клиента и идентификатор пользователя
+type RateLimitKeys func(c *gin.Context) []string
+
+// RateLimit возвращает Gin middleware, ограничивающий частоту запросов.
+// Лимит выбирается по методу и шаблону маршрута, для остальных маршрутов
+// применяется defaultLimiter (nil - без ограничений). Запрос отклоняется с 429,
+// если исчерпана корзина хотя бы одного из ключей.
+func RateLimit(routes map[string]*ratelimit.Limiter, defaultLimiter *ratelimit.Limiter, keys RateLimitKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			limiter = defaultLimiter
+		}
+
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		for _, key := range keys(c) {
+			if allowed, retryAfter := limiter.Allow(key); !allowed {
+				response.HandleError(response.NewTooManyRequestsError(retryAfter), c)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
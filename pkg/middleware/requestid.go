@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+)
+
+// maxRequestIDLength ограничивает идентификатор, пришедший от клиента,
+// чтобы он не раздувал логи и заголовки ответа
+const maxRequestIDLength = 128
+
+// RequestID возвращает Gin middleware, который принимает X-Request-ID клиента
+// или генерирует новый, сохраняет его в контексте запроса и возвращает в ответе
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(helper.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(reqmeta.WithRequestID(c.Request.Context(), id))
+		c.Header(helper.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID допускает только печатные ASCII символы без пробелов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read не возвращает ошибок на поддерживаемых платформах
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/response"
+	log "github.com/sirupsen/logrus"
+)
+
+// RevocationChecker сообщает, отозван ли access токен
+type RevocationChecker interface {
+	Revoked(token string) bool
+}
+
+// Revocation возвращает Gin middleware, отклоняющий запросы с отозванными
+// токенами, не дожидаясь ответа бэкенда
+func Revocation(logger *log.Logger, checker RevocationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := helper.ExtractTokenFromHeaders(c)
+		if token != "" && checker.Revoked(token) {
+			logger.WithContext(c.Request.Context()).WithField("route", c.Request.Method+" "+c.FullPath()).Warn("request with revoked token rejected")
+			response.HandleError(response.NewUnauthorizedError(), c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
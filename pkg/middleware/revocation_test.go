@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/middleware"
+	log "github.com/sirupsen/logrus"
+)
+
+type revokedTokens map[string]bool
+
+func (r revokedTokens) Revoked(token string) bool {
+	return r[token]
+}
+
+func TestRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantHandled   bool
+	}{
+		{name: "revoked token", authorization: "Bearer revoked", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", authorization: "Bearer valid", wantStatus: http.StatusOK, wantHandled: true},
+		{name: "no token", wantStatus: http.StatusOK, wantHandled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+
+			router := gin.New()
+			router.Use(middleware.Revocation(logger, revokedTokens{"revoked": true}))
+			router.GET("/tasks", func(c *gin.Context) {
+				handled = true
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if handled != tt.wantHandled {
+				t.Errorf("handler called = %v, want %v", handled, tt.wantHandled)
+			}
+		})
+	}
+}
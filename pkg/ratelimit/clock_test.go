@@ -0,0 +1,20 @@
+package ratelimit_test
+
+import "time"
+
+// clock - управляемый источник времени для SetClock
+type clock struct {
+	t time.Time
+}
+
+func newClock() *clock {
+	return &clock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
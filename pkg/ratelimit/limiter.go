@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Rule описывает лимит для маршрута в формате "<METHOD> <PATH>=<requests>/<period>",
+// например "POST /auth/login=10/1m"
+type Rule struct {
+	Method string
+	Path   string
+	Limit  int
+	Period time.Duration
+}
+
+func ParseRule(s string) (Rule, error) {
+	route, limit, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit rule %q: missing '='", s)
+	}
+
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit rule %q: route must be '<METHOD> <PATH>'", s)
+	}
+
+	l, p, err := ParseLimit(limit)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rate limit rule %q: %w", s, err)
+	}
+
+	return Rule{
+		Method: strings.ToUpper(method),
+		Path:   strings.TrimSpace(path),
+		Limit:  l,
+		Period: p,
+	}, nil
+}
+
+// ParseLimit разбирает лимит в формате "<requests>/<period>", например "100/1m"
+func ParseLimit(s string) (int, time.Duration, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("limit %q must be '<requests>/<period>'", s)
+	}
+
+	limit, err := strconv.Atoi(count)
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("limit %q: requests must be a positive number", s)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("limit %q: invalid period", s)
+	}
+
+	return limit, d, nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter реализует token bucket с отдельной корзиной на каждый ключ.
+// Корзина вмещает limit токенов и полностью пополняется за period.
+type Limiter struct {
+	capacity float64
+	rate     float64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		capacity:  float64(limit),
+		rate:      float64(limit) / period.Seconds(),
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetClock подменяет источник времени, чтобы пополнение корзин можно было проверить без ожидания
+func (l *Limiter) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = now
+	l.lastSweep = now()
+}
+
+// Allow забирает токен из корзины ключа. Если токенов нет, возвращает
+// время, через которое появится следующий.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep удаляет корзины, которые успели полностью пополниться
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+)
+
+func newLimiter(limit int, period time.Duration) (*ratelimit.Limiter, *clock) {
+	c := newClock()
+	l := ratelimit.NewLimiter(limit, period)
+	l.SetClock(c.now)
+
+	return l, c
+}
+
+func expectAllowed(t *testing.T, l *ratelimit.Limiter, key string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		if ok, wait := l.Allow(key); !ok {
+			t.Fatalf("request %d for %q denied, retry after %s", i+1, key, wait)
+		}
+	}
+}
+
+func expectDenied(t *testing.T, l *ratelimit.Limiter, key string, wantWait time.Duration) {
+	t.Helper()
+
+	ok, wait := l.Allow(key)
+	if ok {
+		t.Fatalf("request for %q allowed, want denied", key)
+	}
+	if wait != wantWait {
+		t.Errorf("retry after = %s, want %s", wait, wantWait)
+	}
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l, _ := newLimiter(3, 3*time.Second)
+
+	expectAllowed(t, l, "client", 3)
+	expectDenied(t, l, "client", time.Second)
+	expectDenied(t, l, "client", time.Second)
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l, c := newLimiter(3, 3*time.Second)
+
+	expectAllowed(t, l, "client", 3)
+
+	c.advance(500 * time.Millisecond)
+	expectDenied(t, l, "client", 500*time.Millisecond)
+
+	c.advance(500 * time.Millisecond)
+	expectAllowed(t, l, "client", 1)
+	expectDenied(t, l, "client", time.Second)
+
+	c.advance(2 * time.Second)
+	expectAllowed(t, l, "client", 2)
+	expectDenied(t, l, "client", time.Second)
+}
+
+func TestLimiterRefillIsCapped(t *testing.T) {
+	l, c := newLimiter(3, 3*time.Second)
+
+	expectAllowed(t, l, "client", 1)
+
+	c.advance(time.Hour)
+	expectAllowed(t, l, "client", 3)
+	expectDenied(t, l, "client", time.Second)
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newLimiter(2, time.Minute)
+
+	expectAllowed(t, l, "first", 2)
+	expectDenied(t, l, "first", 30*time.Second)
+	expectAllowed(t, l, "second", 2)
+}
+
+func TestLimiterForgetsIdleKeys(t *testing.T) {
+	l, c := newLimiter(2, time.Second)
+
+	expectAllowed(t, l, "idle", 2)
+
+	// После очистки корзина ключа создается заново и снова вмещает весь лимит
+	c.advance(2 * time.Minute)
+	expectAllowed(t, l, "other", 1)
+	expectAllowed(t, l, "idle", 2)
+	expectDenied(t, l, "idle", 500*time.Millisecond)
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ratelimit.Rule
+		wantErr bool
+	}{
+		{value: "post /auth/login=10/1m", want: ratelimit.Rule{Method: "POST", Path: "/auth/login", Limit: 10, Period: time.Minute}},
+		{value: " GET /task/ = 5/30s ", want: ratelimit.Rule{Method: "GET", Path: "/task/", Limit: 5, Period: 30 * time.Second}},
+		{value: "POST /auth/login", wantErr: true},
+		{value: "/auth/login=10/1m", wantErr: true},
+		{value: "POST /auth/login=0/1m", wantErr: true},
+		{value: "POST /auth/login=10/0s", wantErr: true},
+		{value: "POST /auth/login=10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ratelimit.ParseRule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
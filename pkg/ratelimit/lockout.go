@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type attempts struct {
+	failures    int
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// Lockout блокирует ключ (например, email) после серии неудачных попыток.
+// Каждая следующая неудача сверх maxAttempts удваивает блокировку вплоть до maxLockout.
+type Lockout struct {
+	maxAttempts int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	items     map[string]*attempts
+	lastSweep time.Time
+}
+
+func NewLockout(maxAttempts int, baseLockout, maxLockout time.Duration) *Lockout {
+	return &Lockout{
+		maxAttempts: maxAttempts,
+		baseLockout: baseLockout,
+		maxLockout:  maxLockout,
+		now:         time.Now,
+		items:       make(map[string]*attempts),
+		lastSweep:   time.Now(),
+	}
+}
+
+// SetClock подменяет источник времени, чтобы окно блокировки можно было проверить без ожидания
+func (l *Lockout) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = now
+	l.lastSweep = now()
+}
+
+// Check возвращает оставшееся время блокировки ключа или 0, если ключ не заблокирован
+func (l *Lockout) Check(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.items[key]
+	if !ok {
+		return 0
+	}
+
+	if wait := a.lockedUntil.Sub(l.now()); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// Fail регистрирует неудачную попытку и возвращает длительность наложенной блокировки
+func (l *Lockout) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	l.sweep(now)
+
+	a, ok := l.items[key]
+	if !ok {
+		a = &attempts{}
+		l.items[key] = a
+	}
+
+	a.failures++
+	a.last = now
+
+	if a.failures < l.maxAttempts {
+		return 0
+	}
+
+	lockout := l.baseLockout
+	for i := l.maxAttempts; i < a.failures && lockout < l.maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.maxLockout {
+		lockout = l.maxLockout
+	}
+
+	a.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// Reset сбрасывает счетчик неудач после успешной попытки
+func (l *Lockout) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.items, key)
+}
+
+// sweep забывает ключи, по которым не было попыток дольше maxLockout
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, a := range l.items {
+		if now.After(a.lockedUntil) && now.Sub(a.last) > l.maxLockout {
+			delete(l.items, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/ratelimit"
+)
+
+func newLockout(maxAttempts int, baseLockout, maxLockout time.Duration) (*ratelimit.Lockout, *clock) {
+	c := newClock()
+	l := ratelimit.NewLockout(maxAttempts, baseLockout, maxLockout)
+	l.SetClock(c.now)
+
+	return l, c
+}
+
+func expectFail(t *testing.T, l *ratelimit.Lockout, key string, want time.Duration) {
+	t.Helper()
+
+	if got := l.Fail(key); got != want {
+		t.Fatalf("Fail(%q) = %s, want %s", key, got, want)
+	}
+}
+
+func expectLocked(t *testing.T, l *ratelimit.Lockout, key string, want time.Duration) {
+	t.Helper()
+
+	if got := l.Check(key); got != want {
+		t.Fatalf("Check(%q) = %s, want %s", key, got, want)
+	}
+}
+
+func TestLockoutAfterMaxAttempts(t *testing.T) {
+	l, c := newLockout(3, time.Minute, 10*time.Minute)
+
+	expectFail(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", 0)
+	expectLocked(t, l, "user@example.com", 0)
+
+	expectFail(t, l, "user@example.com", time.Minute)
+	expectLocked(t, l, "user@example.com", time.Minute)
+
+	c.advance(40 * time.Second)
+	expectLocked(t, l, "user@example.com", 20*time.Second)
+
+	c.advance(20 * time.Second)
+	expectLocked(t, l, "user@example.com", 0)
+	expectLocked(t, l, "other@example.com", 0)
+}
+
+func TestLockoutDoublesUpToMax(t *testing.T) {
+	l, _ := newLockout(2, time.Minute, 5*time.Minute)
+
+	expectFail(t, l, "user@example.com", 0)
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		expectFail(t, l, "user@example.com", want)
+		expectLocked(t, l, "user@example.com", want)
+	}
+}
+
+func TestLockoutKeepsFailuresAfterExpiry(t *testing.T) {
+	l, c := newLockout(2, time.Minute, 10*time.Minute)
+
+	expectFail(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", time.Minute)
+
+	// Истекшая блокировка не обнуляет счетчик: следующая неудача блокирует дольше
+	c.advance(time.Minute)
+	expectLocked(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", 2*time.Minute)
+}
+
+func TestLockoutResetOnSuccess(t *testing.T) {
+	l, _ := newLockout(3, time.Minute, 10*time.Minute)
+
+	expectFail(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", 0)
+	l.Reset("user@example.com")
+
+	expectFail(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", 0)
+	expectLocked(t, l, "user@example.com", 0)
+
+	expectFail(t, l, "user@example.com", time.Minute)
+	l.Reset("user@example.com")
+	expectLocked(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", 0)
+}
+
+func TestLockoutForgetsIdleKeys(t *testing.T) {
+	l, c := newLockout(2, time.Minute, 5*time.Minute)
+
+	expectFail(t, l, "user@example.com", 0)
+	expectFail(t, l, "user@example.com", time.Minute)
+
+	// Ключ без попыток дольше maxLockout удаляется при очистке вместе со счетчиком
+	c.advance(6 * time.Minute)
+	expectFail(t, l, "other@example.com", 0)
+	expectFail(t, l, "user@example.com", 0)
+}
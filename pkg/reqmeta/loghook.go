@@ -0,0 +1,29 @@
+package reqmeta
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// LogHook добавляет request_id к записям logrus, созданным через WithContext
+// с контекстом запроса, в том числе к записям фоновых задач запроса
+type LogHook struct{}
+
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if _, ok := entry.Data[RequestIDKey]; ok {
+		return nil
+	}
+
+	if id := RequestID(entry.Context); id != "" {
+		entry.Data[RequestIDKey] = id
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package reqmeta
+
+import (
+	"context"
+)
+
+// Ключи gRPC метаданных, с которыми шлюз передает сведения о запросе бэкенду
+const (
+	RequestIDKey   = "request_id"
+	ClientIPKey    = "client_ip"
+	UserAgentKey   = "client_user_agent"
+	APIVersionKey  = "api_version"
+	TraceParentKey = "traceparent"
+	TraceStateKey  = "tracestate"
+)
+
+// Metadata - сведения о входящем HTTP запросе, которые нужны бэкенду
+// для корреляции логов со шлюзом
+type Metadata struct {
+	RequestID   string
+	ClientIP    string
+	UserAgent   string
+	APIVersion  string
+	TraceParent string
+	TraceState  string
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с метаданными запроса
+func NewContext(ctx context.Context, md *Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, md)
+}
+
+// FromContext возвращает метаданные запроса, если они есть в контексте
+func FromContext(ctx context.Context) (*Metadata, bool) {
+	md, ok := ctx.Value(contextKey{}).(*Metadata)
+	return md, ok && md != nil
+}
+
+// Pairs возвращает непустые значения в виде пар ключ-значение для metadata.AppendToOutgoingContext
+func (m *Metadata) Pairs() []string {
+	values := [][2]string{
+		{RequestIDKey, m.RequestID},
+		{ClientIPKey, m.ClientIP},
+		{UserAgentKey, m.UserAgent},
+		{APIVersionKey, m.APIVersion},
+		{TraceParentKey, m.TraceParent},
+		{TraceStateKey, m.TraceState},
+	}
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		if v[1] != "" {
+			pairs = append(pairs, v[0], v[1])
+		}
+	}
+
+	return pairs
+}
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
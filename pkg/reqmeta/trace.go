@@ -0,0 +1,63 @@
+package reqmeta
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// sampledFlag - флаг trace-flags по умолчанию для трасс, начатых шлюзом
+const sampledFlag = "01"
+
+// ChildTraceParent продолжает трассу из заголовка traceparent (W3C Trace Context):
+// trace-id и флаги сохраняются, а parent-id заменяется идентификатором шлюза.
+// Если заголовок отсутствует или некорректен, начинается новая трасса.
+func ChildTraceParent(header string) string {
+	traceID, flags, ok := parseTraceParent(header)
+	if !ok {
+		traceID, flags = randomHex(16), sampledFlag
+	}
+
+	return "00-" + traceID + "-" + randomHex(8) + "-" + flags
+}
+
+// parseTraceParent разбирает traceparent версии 00. Нулевые идентификаторы недопустимы.
+func parseTraceParent(header string) (traceID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", "", false
+	}
+
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return "", "", false
+	}
+
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", "", false
+	}
+
+	return traceID, flags, true
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read не возвращает ошибок на поддерживаемых платформах
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -1,23 +1,55 @@
 package helper
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/reqmeta"
 )
 
 const (
 	tokenHeader = "Authorization"
 	tokenPrefix = "Bearer "
+
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+	CSRFTokenHeader    = "X-CSRF-Token"
+
+	APIKeyHeader = "X-API-Key"
+	// APIKeyPrefix отличает API ключи от access токенов в заголовке Authorization
+	APIKeyPrefix = "bhk_"
+
+	RequestIDHeader = "X-Request-ID"
+
+	// IdempotencyKeyHeader - ключ, позволяющий безопасно повторять мутирующие запросы
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// AccessTokenContextKey - ключ gin контекста, в который middleware кладет
+	// access token, выпущенный шлюзом (например, по API ключу)
+	AccessTokenContextKey = "access_token"
 )
 
+// ExtractTokenFromHeaders возвращает access token из заголовка Authorization,
+// а если его нет - из HttpOnly cookie, выставленной при логине в cookie режиме.
+// Токен, подставленный middleware в контекст запроса, имеет приоритет.
 func ExtractTokenFromHeaders(c *gin.Context) string {
+	if token := c.GetString(AccessTokenContextKey); token != "" {
+		return token
+	}
+
 	value := c.GetHeader(tokenHeader)
 
 	if value == "" {
-		return ""
+		token, err := c.Cookie(AccessTokenCookie)
+		if err != nil {
+			return ""
+		}
+		return token
 	}
 
 	if !strings.HasPrefix(value, tokenPrefix) {
@@ -27,25 +59,149 @@ func ExtractTokenFromHeaders(c *gin.Context) string {
 	return strings.TrimPrefix(value, tokenPrefix)
 }
 
-func GetRemoteAddr(r *http.Request) *string {
-	ips, _, _ := net.SplitHostPort(r.RemoteAddr)
-	if r.Header.Get("X-FORWARDED-FOR") != "" {
-		ips = r.Header.Get("X-FORWARDED-FOR")
+// GetRequestID возвращает идентификатор запроса, сохраненный middleware в контексте,
+// а если middleware не подключен - значение заголовка X-Request-ID
+func GetRequestID(c *gin.Context) string {
+	if id := reqmeta.RequestID(c.Request.Context()); id != "" {
+		return id
 	}
 
-	if ips == "" {
-		return nil
+	return c.GetHeader(RequestIDHeader)
+}
+
+// ExtractAPIKey возвращает API ключ из заголовка X-API-Key
+// или из заголовка Authorization, если bearer токен является API ключом
+func ExtractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
 	}
 
-	ipsSlice := strings.Split(ips, ",")
-	if len(ipsSlice) == 0 {
-		return nil
+	value := strings.TrimPrefix(c.GetHeader(tokenHeader), tokenPrefix)
+	if strings.HasPrefix(value, APIKeyPrefix) {
+		return value
+	}
+
+	return ""
+}
+
+// SetTrustedProxies задает сети доверенных прокси. Заголовки X-Forwarded-For
+// и Forwarded учитываются только если запрос пришел от доверенного прокси.
+func SetTrustedProxies(cidrs []string) error {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		nets = append(nets, n)
+	}
+
+	trustedProxies.Store(&nets)
+	return nil
+}
+
+// GetRemoteAddr возвращает IP клиента. Цепочка прокси разбирается справа налево:
+// адреса доверенных прокси пропускаются, первый недоверенный адрес считается клиентом.
+// Если соединение пришло не от доверенного прокси, заголовки игнорируются.
+func GetRemoteAddr(r *http.Request) *string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
 	}
 
-	currentIP := ipsSlice[0]
-	if currentIP == "" {
+	ip := net.ParseIP(remote)
+	if ip == nil {
 		return nil
 	}
 
+	if isTrustedProxy(ip) {
+		chain := forwardedFor(r)
+		for i := len(chain) - 1; i >= 0; i-- {
+			hop := net.ParseIP(chain[i])
+			if hop == nil {
+				break
+			}
+
+			ip = hop
+			if !isTrustedProxy(hop) {
+				break
+			}
+		}
+	}
+
+	currentIP := ip.String()
 	return &currentIP
 }
+
+var trustedProxies atomic.Pointer[[]*net.IPNet]
+
+func isTrustedProxy(ip net.IP) bool {
+	nets := trustedProxies.Load()
+	if nets == nil {
+		return false
+	}
+
+	for _, n := range *nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedFor возвращает цепочку адресов из заголовка Forwarded (RFC 7239),
+// а при его отсутствии - из X-Forwarded-For
+func forwardedFor(r *http.Request) []string {
+	var chain []string
+
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if !ok || !strings.EqualFold(key, "for") {
+						continue
+					}
+					chain = append(chain, stripPort(strings.Trim(v, "\"")))
+				}
+			}
+		}
+		return chain
+	}
+
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			chain = append(chain, stripPort(strings.TrimSpace(hop)))
+		}
+	}
+
+	return chain
+}
+
+func stripPort(addr string) string {
+	if strings.HasPrefix(addr, "[") {
+		if end := strings.Index(addr, "]"); end > 0 {
+			return addr[1:end]
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
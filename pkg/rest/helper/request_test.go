@@ -0,0 +1,232 @@
+package helper_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
+)
+
+func setTrustedProxies(t *testing.T, cidrs ...string) {
+	t.Helper()
+
+	if err := helper.SetTrustedProxies(cidrs); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	t.Cleanup(func() { _ = helper.SetTrustedProxies(nil) })
+}
+
+func TestGetRemoteAddr(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.7"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "untrusted peer",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "198.51.100.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.7"}, "Forwarded": {"for=203.0.113.8"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "remote addr without port",
+			remoteAddr: "10.0.0.1",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "trusted proxy without headers",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "spoofed left-most entries",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"127.0.0.1, 6.6.6.6, 203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed entries in a separate header line",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"127.0.0.1", "203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "chain of trusted proxies",
+			trusted:    []string{"10.0.0.0/8", "192.168.0.0/16"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"6.6.6.6, 203.0.113.7, 192.168.1.1, 10.0.0.2"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "only trusted proxies in chain",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "bare trusted proxy address",
+			trusted:    []string{"10.0.0.1"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "ipv6 trusted proxy",
+			trusted:    []string{"::1"},
+			remoteAddr: "[::1]:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"2001:db8::7"}},
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "x-forwarded-for with port",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.7:4711"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forwarded ipv4",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {"for=192.0.2.60;proto=http;by=10.0.0.1"}},
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "forwarded quoted ipv6 with port",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {`for=6.6.6.6, For="[2001:db8:cafe::17]:4711"`}},
+			want:       "2001:db8:cafe::17",
+		},
+		{
+			name:       "forwarded quoted ipv4 with port",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {`for="192.0.2.60:8080"`}},
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "forwarded chain of trusted proxies",
+			trusted:    []string{"10.0.0.0/8", "2001:db8:1::/48"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {`for=6.6.6.6, for=203.0.113.7`, `for="[2001:db8:1::2]"`}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forwarded takes precedence over x-forwarded-for",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {"for=203.0.113.7"}, "X-Forwarded-For": {"203.0.113.8"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "malformed right-most entry",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.7, not-an-ip"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "malformed entry behind trusted proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"not-an-ip, 10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "empty x-forwarded-for",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {""}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "forwarded obfuscated identifier",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {"for=_hidden"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "forwarded without for",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {"proto=https;by=10.0.0.1"}, "X-Forwarded-For": {"6.6.6.6"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "unterminated ipv6 bracket",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string][]string{"Forwarded": {`for="[2001:db8::1`}},
+			want:       "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTrustedProxies(t, tt.trusted...)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(key, value)
+				}
+			}
+
+			got := helper.GetRemoteAddr(r)
+			if got == nil {
+				t.Fatalf("GetRemoteAddr() = nil, want %s", tt.want)
+			}
+			if *got != tt.want {
+				t.Errorf("GetRemoteAddr() = %s, want %s", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRemoteAddrInvalidPeer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "not-an-ip:5000"
+
+	if got := helper.GetRemoteAddr(r); got != nil {
+		t.Errorf("GetRemoteAddr() = %s, want nil", *got)
+	}
+}
+
+func TestSetTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		wantErr bool
+	}{
+		{name: "networks and addresses", cidrs: []string{"10.0.0.0/8", " 192.0.2.1 ", "::1", ""}},
+		{name: "invalid network", cidrs: []string{"10.0.0.0/33"}, wantErr: true},
+		{name: "invalid address", cidrs: []string{"proxy.local"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { _ = helper.SetTrustedProxies(nil) })
+
+			if err := helper.SetTrustedProxies(tt.cidrs); (err != nil) != tt.wantErr {
+				t.Errorf("SetTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,13 @@ type Error interface {
 	GetHTTPStatus() int
 }
 
+// CodedError реализуют ошибки со стабильным машиночитаемым кодом,
+// который отдается клиенту вместе с сообщением
+type CodedError interface {
+	Error
+	Code() ErrCode
+}
+
 type BaseError struct{}
 
 func (e *BaseError) GetHTTPStatus() int {
@@ -6,4 +6,16 @@ const (
 	InvalidRequestStructure ErrCode = 10001
 	MissedValue             ErrCode = 11001
 	EmptyField              ErrCode = 11002
+	InvalidValue            ErrCode = 11003
+
+	// Ошибки, полученные от бэкенда или возникшие при обращении к нему
+	NotFound            ErrCode = 20001
+	PermissionDenied    ErrCode = 20002
+	Conflict            ErrCode = 20003
+	FailedPrecondition  ErrCode = 20004
+	ServiceUnavailable  ErrCode = 20005
+	GatewayTimeout      ErrCode = 20006
+	ClientClosedRequest ErrCode = 20007
+	NotImplemented      ErrCode = 20008
+	TooManyRequests     ErrCode = 20009
 )
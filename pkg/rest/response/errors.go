@@ -3,6 +3,7 @@ package response
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -75,6 +76,312 @@ func NewUnauthorizedError() *UnauthorizedError {
 
 }
 
+type ForbiddenError struct {
+	BaseError
+}
+
+func (e *ForbiddenError) PublicMessage() string {
+	return "forbidden"
+}
+
+func (e *ForbiddenError) Code() ErrCode {
+	return PermissionDenied
+}
+
+func (e *ForbiddenError) GetHTTPStatus() int {
+	return http.StatusForbidden
+}
+
+func NewForbiddenError() *ForbiddenError {
+	return &ForbiddenError{}
+}
+
+type NotFoundError struct {
+	BaseError
+}
+
+func (e *NotFoundError) PublicMessage() string {
+	return "not found"
+}
+
+func (e *NotFoundError) Code() ErrCode {
+	return NotFound
+}
+
+func (e *NotFoundError) GetHTTPStatus() int {
+	return http.StatusNotFound
+}
+
+func NewNotFoundError() *NotFoundError {
+	return &NotFoundError{}
+}
+
+type InvalidRefreshTokenError struct {
+	BaseError
+}
+
+func (e *InvalidRefreshTokenError) PublicMessage() string {
+	return "invalid refresh token"
+}
+
+func (e *InvalidRefreshTokenError) GetHTTPStatus() int {
+	return http.StatusUnauthorized
+}
+
+func NewInvalidRefreshTokenError() *InvalidRefreshTokenError {
+	return &InvalidRefreshTokenError{}
+}
+
+type CSRFError struct {
+	BaseError
+}
+
+func (e *CSRFError) PublicMessage() string {
+	return "invalid csrf token"
+}
+
+func (e *CSRFError) GetHTTPStatus() int {
+	return http.StatusForbidden
+}
+
+func NewCSRFError() *CSRFError {
+	return &CSRFError{}
+}
+
+type SSOLoginError struct {
+	BaseError
+}
+
+func (e *SSOLoginError) PublicMessage() string {
+	return "single sign-on failed"
+}
+
+func (e *SSOLoginError) GetHTTPStatus() int {
+	return http.StatusUnauthorized
+}
+
+func NewSSOLoginError() *SSOLoginError {
+	return &SSOLoginError{}
+}
+
+type IdentityNotLinkedError struct {
+	BaseError
+}
+
+func (e *IdentityNotLinkedError) PublicMessage() string {
+	return "identity is not linked to an account"
+}
+
+func (e *IdentityNotLinkedError) GetHTTPStatus() int {
+	return http.StatusForbidden
+}
+
+func NewIdentityNotLinkedError() *IdentityNotLinkedError {
+	return &IdentityNotLinkedError{}
+}
+
+type InvalidInvitationError struct {
+	BaseError
+}
+
+func (e *InvalidInvitationError) PublicMessage() string {
+	return "invalid invitation"
+}
+
+func (e *InvalidInvitationError) GetHTTPStatus() int {
+	return http.StatusForbidden
+}
+
+func NewInvalidInvitationError() *InvalidInvitationError {
+	return &InvalidInvitationError{}
+}
+
+type InvalidTwoFactorCodeError struct {
+	BaseError
+}
+
+func (e *InvalidTwoFactorCodeError) PublicMessage() string {
+	return "invalid two-factor code"
+}
+
+func (e *InvalidTwoFactorCodeError) GetHTTPStatus() int {
+	return http.StatusUnauthorized
+}
+
+func NewInvalidTwoFactorCodeError() *InvalidTwoFactorCodeError {
+	return &InvalidTwoFactorCodeError{}
+}
+
+type TwoFactorEnabledError struct {
+	BaseError
+}
+
+func (e *TwoFactorEnabledError) PublicMessage() string {
+	return "two-factor authentication is already enabled"
+}
+
+func (e *TwoFactorEnabledError) GetHTTPStatus() int {
+	return http.StatusConflict
+}
+
+func NewTwoFactorEnabledError() *TwoFactorEnabledError {
+	return &TwoFactorEnabledError{}
+}
+
+type ConflictError struct {
+	BaseError
+}
+
+func (e *ConflictError) PublicMessage() string {
+	return "conflict"
+}
+
+func (e *ConflictError) Code() ErrCode {
+	return Conflict
+}
+
+func (e *ConflictError) GetHTTPStatus() int {
+	return http.StatusConflict
+}
+
+func NewConflictError() *ConflictError {
+	return &ConflictError{}
+}
+
+type FailedPreconditionError struct {
+	BaseError
+}
+
+func (e *FailedPreconditionError) PublicMessage() string {
+	return "failed precondition"
+}
+
+func (e *FailedPreconditionError) Code() ErrCode {
+	return FailedPrecondition
+}
+
+func (e *FailedPreconditionError) GetHTTPStatus() int {
+	return http.StatusBadRequest
+}
+
+func NewFailedPreconditionError() *FailedPreconditionError {
+	return &FailedPreconditionError{}
+}
+
+type NotImplementedError struct {
+	BaseError
+}
+
+func (e *NotImplementedError) PublicMessage() string {
+	return "not implemented"
+}
+
+func (e *NotImplementedError) Code() ErrCode {
+	return NotImplemented
+}
+
+func (e *NotImplementedError) GetHTTPStatus() int {
+	return http.StatusNotImplemented
+}
+
+func NewNotImplementedError() *NotImplementedError {
+	return &NotImplementedError{}
+}
+
+// StatusClientClosedRequest - нестандартный статус (nginx), означающий, что клиент
+// закрыл соединение до получения ответа
+const StatusClientClosedRequest = 499
+
+type ClientClosedRequestError struct {
+	BaseError
+}
+
+func (e *ClientClosedRequestError) PublicMessage() string {
+	return "client closed request"
+}
+
+func (e *ClientClosedRequestError) Code() ErrCode {
+	return ClientClosedRequest
+}
+
+func (e *ClientClosedRequestError) GetHTTPStatus() int {
+	return StatusClientClosedRequest
+}
+
+func NewClientClosedRequestError() *ClientClosedRequestError {
+	return &ClientClosedRequestError{}
+}
+
+type GatewayTimeoutError struct {
+	BaseError
+}
+
+func (e *GatewayTimeoutError) PublicMessage() string {
+	return "gateway timeout"
+}
+
+func (e *GatewayTimeoutError) Code() ErrCode {
+	return GatewayTimeout
+}
+
+func (e *GatewayTimeoutError) GetHTTPStatus() int {
+	return http.StatusGatewayTimeout
+}
+
+func NewGatewayTimeoutError() *GatewayTimeoutError {
+	return &GatewayTimeoutError{}
+}
+
+type ServiceUnavailableError struct {
+	BaseError
+	retryAfter time.Duration
+}
+
+func (e *ServiceUnavailableError) PublicMessage() string {
+	return "service unavailable"
+}
+
+func (e *ServiceUnavailableError) Code() ErrCode {
+	return ServiceUnavailable
+}
+
+func (e *ServiceUnavailableError) GetHTTPStatus() int {
+	return http.StatusServiceUnavailable
+}
+
+func (e *ServiceUnavailableError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func NewServiceUnavailableError(retryAfter time.Duration) *ServiceUnavailableError {
+	return &ServiceUnavailableError{retryAfter: retryAfter}
+}
+
+type TooManyRequestsError struct {
+	BaseError
+	retryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) PublicMessage() string {
+	return "too many requests"
+}
+
+func (e *TooManyRequestsError) Code() ErrCode {
+	return TooManyRequests
+}
+
+func (e *TooManyRequestsError) GetHTTPStatus() int {
+	return http.StatusTooManyRequests
+}
+
+func (e *TooManyRequestsError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func NewTooManyRequestsError(retryAfter time.Duration) *TooManyRequestsError {
+	return &TooManyRequestsError{retryAfter: retryAfter}
+}
+
 type ErrorMessage struct {
 	Code    ErrCode `json:"code"`
 	Message string  `json:"message"`
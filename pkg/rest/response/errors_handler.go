@@ -1,11 +1,31 @@
 package response
 
 import (
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/markgregr/bestHack_support_REST_server/pkg/rest/helper"
 )
 
+// RetryableError реализуют ошибки, после которых клиент может повторить запрос позже
+type RetryableError interface {
+	Error
+	RetryAfter() time.Duration
+}
+
 func HandleError(err Error, c *gin.Context) {
-	c.JSON(err.GetHTTPStatus(), ParseError(err))
+	if e, ok := err.(RetryableError); ok && e.RetryAfter() > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter().Seconds()))))
+	}
+
+	body := ParseError(err)
+	if id := helper.GetRequestID(c); id != "" {
+		body["request_id"] = id
+	}
+
+	c.JSON(err.GetHTTPStatus(), body)
 }
 
 // ParseError determines the error type and creates a map with the error description.
@@ -20,6 +40,11 @@ func ParseError(err Error) map[string]interface{} {
 			"errors":  e.Errors(),
 			"message": e.PublicMessage(),
 		}
+	case CodedError:
+		return map[string]interface{}{
+			"code":    e.Code(),
+			"message": e.PublicMessage(),
+		}
 	default:
 		return map[string]interface{}{
 			"message": err.PublicMessage(),
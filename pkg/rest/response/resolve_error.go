@@ -1,6 +1,13 @@
 package response
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/markgregr/bestHack_support_REST_server/pkg/circuitbreaker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
@@ -11,11 +18,26 @@ var (
 )
 
 func ResolveError(err error) Error {
+	// Автомат бэкенда разомкнут: вызов не выполнялся, клиент может повторить позже
+	var openErr *circuitbreaker.OpenError
+	if errors.As(err, &openErr) {
+		return NewServiceUnavailableError(openErr.RetryAfter)
+	}
+
+	// Ошибки контекста запроса: клиент отключился или истек дедлайн маршрута
+	switch {
+	case errors.Is(err, context.Canceled):
+		return NewClientClosedRequestError()
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewGatewayTimeoutError()
+	}
+
 	st, ok := status.FromError(err)
 	if !ok {
 		return NewInternalError()
 	}
 
+	// SSO различает эти ошибки только по тексту сообщения
 	switch st.Message() {
 	case ErrUserExist:
 		return NewUserExistError()
@@ -23,7 +45,86 @@ func ResolveError(err error) Error {
 		return NewInvalidCredentialsError()
 	case ErrUnauthorized:
 		return NewUnauthorizedError()
+	}
+
+	return resolveStatus(st)
+}
+
+// resolveStatus сопоставляет gRPC код HTTP ошибке. Детали статуса (BadRequest,
+// PreconditionFailure, RetryInfo) уточняют ответ, если бэкенд их передал.
+func resolveStatus(st *status.Status) Error {
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		if verr := violations(st); verr != nil {
+			return verr
+		}
+		verr := NewValidationError()
+		verr.SetError(GeneralErrorKey, InvalidValue, st.Message())
+		return verr
+	case codes.FailedPrecondition:
+		if verr := violations(st); verr != nil {
+			return verr
+		}
+		return NewFailedPreconditionError()
+	case codes.NotFound:
+		return NewNotFoundError()
+	case codes.AlreadyExists, codes.Aborted:
+		return NewConflictError()
+	case codes.PermissionDenied:
+		return NewForbiddenError()
+	case codes.Unauthenticated:
+		return NewUnauthorizedError()
+	case codes.ResourceExhausted:
+		return NewTooManyRequestsError(retryDelay(st))
+	case codes.Unavailable:
+		return NewServiceUnavailableError(retryDelay(st))
+	case codes.Canceled:
+		return NewClientClosedRequestError()
+	case codes.DeadlineExceeded:
+		return NewGatewayTimeoutError()
+	case codes.Unimplemented:
+		return NewNotImplementedError()
 	default:
 		return NewInternalError()
 	}
 }
+
+// violations переносит нарушения из BadRequest и PreconditionFailure в ValidationError.
+// Возвращает nil, если таких деталей нет.
+func violations(st *status.Status) *ValidationError {
+	var verr *ValidationError
+	set := func(key, description string) {
+		if verr == nil {
+			verr = NewValidationError()
+		}
+		if key == "" {
+			key = GeneralErrorKey
+		}
+		verr.SetError(key, InvalidValue, description)
+	}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				set(v.GetField(), v.GetDescription())
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.GetViolations() {
+				set(v.GetSubject(), v.GetDescription())
+			}
+		}
+	}
+
+	return verr
+}
+
+func retryDelay(st *status.Status) time.Duration {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,201 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader держит в памяти сертификат, ключ и CA bundle и перечитывает их,
+// когда файлы изменяются на диске. Если новый файл не удалось загрузить,
+// продолжает использоваться предыдущая версия.
+type Reloader struct {
+	log      *logrus.Entry
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+// New загружает файлы и возвращает ошибку, если хотя бы один из заданных
+// файлов отсутствует или не разбирается. Пара certFile/keyFile задается целиком или не задается.
+func New(log *logrus.Entry, certFile, keyFile, caFile string, interval time.Duration) (*Reloader, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("tls: both certificate and key files must be set")
+	}
+
+	r := &Reloader{
+		log:      log.WithField("component", "tlsreload.Reloader"),
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+		modTime:  make(map[string]time.Time),
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Reloader) HasCertificate() bool {
+	return r.certFile != ""
+}
+
+func (r *Reloader) HasCA() bool {
+	return r.caFile != ""
+}
+
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+func (r *Reloader) CertPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pool
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := r.Certificate(); cert != nil {
+		return cert, nil
+	}
+
+	// Пустой сертификат означает, что клиент не предъявляет сертификат
+	return &tls.Certificate{}, nil
+}
+
+// VerifyPeer проверяет цепочку сертификатов собеседника по текущему CA bundle.
+// Используется вместе с InsecureSkipVerify, чтобы CA можно было менять без перезапуска.
+func (r *Reloader) VerifyPeer(cs tls.ConnectionState, usage x509.ExtKeyUsage, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: peer did not present a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         r.CertPool(),
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+
+	return err
+}
+
+// Start периодически проверяет время изменения файлов и перечитывает их
+func (r *Reloader) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			if err := r.reload(); err != nil {
+				r.log.WithError(err).Error("failed to reload tls files, keeping previous version")
+				continue
+			}
+			r.log.Info("tls files reloaded")
+		}
+	}
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reloader) reload() error {
+	modTime := make(map[string]time.Time)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTime[file] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		c, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("tls: load key pair %s, %s: %w", r.certFile, r.keyFile, err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("tls: read ca bundle: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in ca bundle %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = cert
+	r.pool = pool
+	r.modTime = modTime
+
+	return nil
+}